@@ -35,10 +35,13 @@ func main() {
 	docRepo := repository.NewDocumentRepository(db)
 	chunkRepo := repository.NewChunkRepository(db)
 	entityRepo := repository.NewEntityRepository(db)
+	embeddingCacheRepo := repository.NewEmbeddingCacheRepository(db)
+	queryLogRepo := repository.NewQueryLogRepository(db)
+	promptRepo := repository.NewPromptTemplateRepository(db)
 
 	// Initialize services with Eino components
 	log.Println("Initializing Eino components...")
-	services, err := service.InitServices(cfg, docRepo, chunkRepo, entityRepo)
+	services, err := service.InitServices(cfg, docRepo, chunkRepo, entityRepo, embeddingCacheRepo, queryLogRepo, promptRepo)
 	if err != nil {
 		log.Fatalf("Failed to initialize services: %v", err)
 	}
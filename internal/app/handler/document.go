@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -103,4 +104,31 @@ func (h *DocumentHandler) Process(c *gin.Context) {
 	}
 
 	Success(c, gin.H{"message": "document processing started"})
-}
\ No newline at end of file
+}
+
+// GetChunk handles get chunk by document ID and chunk index
+func (h *DocumentHandler) GetChunk(c *gin.Context) {
+	docID := c.Param("id")
+	if docID == "" {
+		BadRequest(c, "document id is required")
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		BadRequest(c, "chunk index must be a non-negative integer")
+		return
+	}
+
+	chunk, err := h.docService.GetChunk(docID, index)
+	if err != nil {
+		if errors.Is(err, service.ErrDocumentNotFound) || errors.Is(err, service.ErrChunkNotFound) {
+			NotFound(c, err.Error())
+			return
+		}
+		InternalError(c, err.Error())
+		return
+	}
+
+	Success(c, chunk)
+}
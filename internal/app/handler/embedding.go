@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zibianqu/eino_study/internal/app/service"
+	"github.com/zibianqu/eino_study/internal/eino/graph"
+)
+
+type EmbeddingHandler struct {
+	embeddingService service.EmbeddingService
+}
+
+func NewEmbeddingHandler(embeddingService service.EmbeddingService) *EmbeddingHandler {
+	return &EmbeddingHandler{
+		embeddingService: embeddingService,
+	}
+}
+
+// CacheStats handles get embedding cache statistics
+func (h *EmbeddingHandler) CacheStats(c *gin.Context) {
+	stats, err := h.embeddingService.CacheStats()
+	if err != nil {
+		InternalError(c, err.Error())
+		return
+	}
+
+	Success(c, stats)
+}
+
+// StartReembed handles starting the re-embedding job
+func (h *EmbeddingHandler) StartReembed(c *gin.Context) {
+	status, err := h.embeddingService.StartReembed()
+	if err != nil {
+		if errors.Is(err, graph.ErrReembedRunning) {
+			Error(c, http.StatusConflict, err.Error())
+			return
+		}
+		InternalError(c, err.Error())
+		return
+	}
+
+	Success(c, status)
+}
+
+// ReembedStatus handles get re-embedding job status
+func (h *EmbeddingHandler) ReembedStatus(c *gin.Context) {
+	Success(c, h.embeddingService.ReembedStatus())
+}
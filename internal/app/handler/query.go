@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/zibianqu/eino_study/internal/app/service"
+	"github.com/zibianqu/eino_study/internal/eino/chatmodel"
+	"github.com/zibianqu/eino_study/internal/eino/prompt"
 	"github.com/zibianqu/eino_study/pkg/api"
 )
 
@@ -24,15 +30,68 @@ func (h *QueryHandler) Query(c *gin.Context) {
 		return
 	}
 
-	if req.TopK <= 0 {
-		req.TopK = 5
+	if req.Stream {
+		h.queryStream(c, &req)
+		return
 	}
 
-	resp, err := h.ragService.Query(req.Query, req.TopK)
+	resp, err := h.ragService.Query(c.Request.Context(), &req, apiKey(c))
 	if err != nil {
-		InternalError(c, err.Error())
+		queryError(c, err)
 		return
 	}
 
 	Success(c, resp)
-}
\ No newline at end of file
+}
+
+// queryStream answers a query with server-sent events. Errors before the
+// first event get a regular error response, later ones an error event; the
+// LLM call is cancelled when the client disconnects.
+func (h *QueryHandler) queryStream(c *gin.Context, req *api.QueryRequest) {
+	ctx := c.Request.Context()
+	err := h.ragService.QueryStream(ctx, req, apiKey(c), func(event string, data any) error {
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+		}
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	if !c.Writer.Written() {
+		queryError(c, err)
+		return
+	}
+	c.SSEvent(api.QueryEventError, &api.QueryErrorEvent{Message: err.Error()})
+	c.Writer.Flush()
+}
+
+// queryError responds with the status matching a failed query
+func queryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, prompt.ErrNotFound):
+		BadRequest(c, err.Error())
+	case errors.Is(err, chatmodel.ErrUnavailable):
+		Error(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		InternalError(c, err.Error())
+	}
+}
+
+// apiKey returns the API key the caller sent in the X-API-Key header or as a
+// bearer token, usage is accounted per key
+func apiKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	auth := c.GetHeader("Authorization")
+	if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zibianqu/eino_study/internal/app/repository"
+	"github.com/zibianqu/eino_study/internal/app/service"
+	"github.com/zibianqu/eino_study/pkg/api"
+)
+
+// usageDateLayout is the format of the from and to parameters, which are UTC
+// days like the day buckets
+const usageDateLayout = "2006-01-02"
+
+type UsageHandler struct {
+	usageService service.UsageService
+}
+
+func NewUsageHandler(usageService service.UsageService) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+// Summary handles get token usage and cost aggregated from the query log
+func (h *UsageHandler) Summary(c *gin.Context) {
+	var req api.UsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		BadRequest(c, err.Error())
+		return
+	}
+
+	filter := &repository.QueryLogFilter{
+		Model:  req.Model,
+		APIKey: req.APIKey,
+	}
+	if req.From != "" {
+		from, err := time.ParseInLocation(usageDateLayout, req.From, time.UTC)
+		if err != nil {
+			BadRequest(c, "invalid from date, expected YYYY-MM-DD")
+			return
+		}
+		filter.From = from
+	}
+	if req.To != "" {
+		to, err := time.ParseInLocation(usageDateLayout, req.To, time.UTC)
+		if err != nil {
+			BadRequest(c, "invalid to date, expected YYYY-MM-DD")
+			return
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = strings.Join([]string{repository.UsageByDay, repository.UsageByModel, repository.UsageByAPIKey}, ",")
+	}
+	for _, group := range strings.Split(groupBy, ",") {
+		group = strings.TrimSpace(group)
+		switch group {
+		case repository.UsageByDay, repository.UsageByModel, repository.UsageByAPIKey:
+			// A dimension given twice would be selected twice
+			if !slices.Contains(filter.GroupBy, group) {
+				filter.GroupBy = append(filter.GroupBy, group)
+			}
+		case "", "none":
+		default:
+			BadRequest(c, "invalid group_by "+group+", expected day, model or api_key")
+			return
+		}
+	}
+
+	resp, err := h.usageService.Summary(filter)
+	if err != nil {
+		InternalError(c, err.Error())
+		return
+	}
+
+	Success(c, resp)
+}
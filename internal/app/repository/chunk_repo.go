@@ -1,6 +1,12 @@
 package repository
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/zibianqu/eino_study/internal/model"
 	"gorm.io/gorm"
 )
@@ -8,11 +14,44 @@ import (
 type ChunkRepository interface {
 	Create(chunk *model.DocumentChunk) error
 	BatchCreate(chunks []*model.DocumentChunk) error
+	BatchCreateWithChildren(parents []*model.DocumentChunk, children [][]*model.DocumentChunk) error
 	GetByDocID(docID string) ([]*model.DocumentChunk, error)
+	GetByIDs(ids []int) ([]*model.DocumentChunk, error)
+	GetByDocIDAndIndex(docID string, index int) (*model.DocumentChunk, error)
 	DeleteByDocID(docID string) error
-	SearchSimilar(embedding string, topK int, threshold float64) ([]*model.DocumentChunk, error)
+	SearchSimilar(embedding string, topK int, threshold float64, filter *ChunkFilter) ([]*model.DocumentChunk, error)
+	SearchLexical(query string, topK int, filter *ChunkFilter) ([]*model.DocumentChunk, error)
+
+	// Embedding model migration. With next set, the methods work on the
+	// shadow column embedding_next, which is filled with the vectors of a new
+	// model while embedding keeps serving queries.
+	EmbeddingDimension() (int, error)
+	SetEmbeddingDimension(dimension int) error
+	CountStaleEmbeddings(embeddingModel string, next bool) (int64, error)
+	ListStaleEmbeddings(embeddingModel string, next bool, afterID, limit int) ([]*model.DocumentChunk, error)
+	UpdateEmbeddings(chunks []*model.DocumentChunk, next bool) error
+	PromoteNextEmbeddings(embeddingModel string, dimension int) error
+}
+
+// ChunkFilter restricts a similarity search. Zero values match all chunks.
+type ChunkFilter struct {
+	DocIDs    []string
+	FileType  string         // Extension of the document, e.g. ".pdf", compared case-insensitively
+	Metadata  map[string]any // Chunk metadata must contain these keys with equal values
+	CTimeFrom time.Time      // Documents created at or after this time
+	CTimeTo   time.Time      // Documents created before this time
 }
 
+// ErrStaleEmbeddings is returned when promoting the shadow embeddings while
+// some chunks have not been re-embedded yet
+var ErrStaleEmbeddings = errors.New("some chunks have not been re-embedded")
+
+// maxIndexedDimension is the largest vector pgvector can index
+const maxIndexedDimension = 2000
+
+// embeddingIndexLists is the number of lists of the ivfflat index on embedding
+const embeddingIndexLists = 100
+
 type chunkRepository struct {
 	db *gorm.DB
 }
@@ -29,27 +68,286 @@ func (r *chunkRepository) BatchCreate(chunks []*model.DocumentChunk) error {
 	return r.db.CreateInBatches(chunks, 100).Error
 }
 
+// BatchCreateWithChildren stores parent chunks and links children[i] to parents[i], in one transaction
+func (r *chunkRepository) BatchCreateWithChildren(parents []*model.DocumentChunk, children [][]*model.DocumentChunk) error {
+	if len(parents) != len(children) {
+		return fmt.Errorf("got children for %d parents, want %d", len(children), len(parents))
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(parents, 100).Error; err != nil {
+			return err
+		}
+
+		var all []*model.DocumentChunk
+		for i, parent := range parents {
+			for _, child := range children[i] {
+				child.ParentID = &parent.ID
+				all = append(all, child)
+			}
+		}
+		if len(all) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(all, 100).Error
+	})
+}
+
+func (r *chunkRepository) GetByIDs(ids []int) ([]*model.DocumentChunk, error) {
+	var chunks []*model.DocumentChunk
+	if len(ids) == 0 {
+		return chunks, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&chunks).Error
+	return chunks, err
+}
+
 func (r *chunkRepository) GetByDocID(docID string) ([]*model.DocumentChunk, error) {
 	var chunks []*model.DocumentChunk
 	err := r.db.Where("doc_id = ?", docID).Order("chunk_index").Find(&chunks).Error
 	return chunks, err
 }
 
+func (r *chunkRepository) GetByDocIDAndIndex(docID string, index int) (*model.DocumentChunk, error) {
+	var chunk model.DocumentChunk
+	err := r.db.Where("doc_id = ? AND chunk_index = ?", docID, index).First(&chunk).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chunk, nil
+}
+
 func (r *chunkRepository) DeleteByDocID(docID string) error {
 	return r.db.Where("doc_id = ?", docID).Delete(&model.DocumentChunk{}).Error
 }
 
-func (r *chunkRepository) SearchSimilar(embedding string, topK int, threshold float64) ([]*model.DocumentChunk, error) {
+// SearchSimilar returns the chunks most similar to embedding, filtered in
+// the same query so that topK counts only matching chunks. The vector index
+// probes one of its lists by default and the filter is applied to the chunks
+// found there, so a selective filter could miss matching chunks; filtered
+// searches probe every list instead.
+func (r *chunkRepository) SearchSimilar(embedding string, topK int, threshold float64, filter *ChunkFilter) ([]*model.DocumentChunk, error) {
 	var chunks []*model.DocumentChunk
+
+	join, conditions, args, err := filterConditions(filter)
+	if err != nil {
+		return nil, err
+	}
+	filtered := len(conditions) > 0
+	conditions = append([]string{
+		"c.embedding IS NOT NULL",
+		"1 - (c.embedding <=> ?::vector) > ?",
+	}, conditions...)
+	args = append([]any{embedding, embedding, threshold}, args...)
+
 	// Using pgvector cosine similarity search
-	query := `
-		SELECT id, doc_id, chunk_index, content, metadata, ctime,
-		       1 - (embedding <=> ?::vector) as similarity
-		FROM document_chunks
-		WHERE 1 - (embedding <=> ?::vector) > ?
-		ORDER BY embedding <=> ?::vector
+	query := fmt.Sprintf(`
+		SELECT c.id, c.doc_id, c.chunk_index, c.chunk_type, c.parent_id, c.content, c.metadata, c.ctime,
+		       1 - (c.embedding <=> ?::vector) as similarity
+		FROM document_chunks c
+		%s
+		WHERE %s
+		ORDER BY c.embedding <=> ?::vector
 		LIMIT ?
-	`
-	err := r.db.Raw(query, embedding, embedding, threshold, embedding, topK).Scan(&chunks).Error
+	`, join, strings.Join(conditions, "\n\t\t  AND "))
+	args = append(args, embedding, topK)
+
+	if !filtered {
+		err = r.db.Raw(query, args...).Scan(&chunks).Error
+		return chunks, err
+	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL ivfflat.probes = %d", embeddingIndexLists)).Error; err != nil {
+			return err
+		}
+		return tx.Raw(query, args...).Scan(&chunks).Error
+	})
 	return chunks, err
-}
\ No newline at end of file
+}
+
+// SearchLexical returns the chunks sharing the most terms with query, using
+// the full-text index on content_tsv. Terms are words and identifiers such
+// as error codes as a whole and in parts, and Chinese characters and
+// character pairs, see search_terms in the migrations.
+func (r *chunkRepository) SearchLexical(query string, topK int, filter *ChunkFilter) ([]*model.DocumentChunk, error) {
+	var chunks []*model.DocumentChunk
+
+	join, conditions, args, err := filterConditions(filter)
+	if err != nil {
+		return nil, err
+	}
+	conditions = append([]string{
+		"c.content_tsv @@ q.query",
+		"c.chunk_type <> ?",
+	}, conditions...)
+	args = append([]any{query, model.ChunkTypeParent}, args...)
+
+	// Normalising the rank by document length favours short chunks that
+	// match many terms over long chunks that match a few
+	sql := fmt.Sprintf(`
+		SELECT c.id, c.doc_id, c.chunk_index, c.chunk_type, c.parent_id, c.content, c.metadata, c.ctime,
+		       ts_rank(c.content_tsv, q.query, 1) as rank
+		FROM document_chunks c
+		CROSS JOIN (SELECT search_query(?) AS query) q
+		%s
+		WHERE %s
+		ORDER BY rank DESC, c.id
+		LIMIT ?
+	`, join, strings.Join(conditions, "\n\t\t  AND "))
+	args = append(args, topK)
+
+	err = r.db.Raw(sql, args...).Scan(&chunks).Error
+	return chunks, err
+}
+
+// filterConditions translates a chunk filter to SQL conditions on the
+// chunks c and, when needed, their documents d
+func filterConditions(filter *ChunkFilter) (join string, conditions []string, args []any, err error) {
+	if filter == nil {
+		return "", nil, nil, nil
+	}
+
+	if len(filter.DocIDs) > 0 {
+		conditions = append(conditions, "c.doc_id IN ?")
+		args = append(args, filter.DocIDs)
+	}
+	if len(filter.Metadata) > 0 {
+		metadata, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed to encode metadata filter: %w", err)
+		}
+		conditions = append(conditions, "c.metadata @> ?::jsonb")
+		args = append(args, string(metadata))
+	}
+	if filter.FileType != "" {
+		conditions = append(conditions, "LOWER(d.file_type) = LOWER(?)")
+		args = append(args, filter.FileType)
+	}
+	if !filter.CTimeFrom.IsZero() {
+		conditions = append(conditions, "d.ctime >= ?")
+		args = append(args, filter.CTimeFrom)
+	}
+	if !filter.CTimeTo.IsZero() {
+		conditions = append(conditions, "d.ctime < ?")
+		args = append(args, filter.CTimeTo)
+	}
+	if filter.FileType != "" || !filter.CTimeFrom.IsZero() || !filter.CTimeTo.IsZero() {
+		join = "JOIN documents d ON d.doc_id = c.doc_id"
+	}
+	return join, conditions, args, nil
+}
+
+// EmbeddingDimension returns the dimension of the embedding column, or 0 if unconstrained
+func (r *chunkRepository) EmbeddingDimension() (int, error) {
+	var typmod int
+	err := r.db.Raw(`
+		SELECT atttypmod FROM pg_attribute
+		WHERE attrelid = 'document_chunks'::regclass AND attname = 'embedding' AND NOT attisdropped
+	`).Scan(&typmod).Error
+	if err != nil {
+		return 0, err
+	}
+	return max(typmod, 0), nil
+}
+
+// SetEmbeddingDimension constrains the unconstrained embedding column of a
+// fresh install to dimension and creates the vector index, which needs one
+func (r *chunkRepository) SetEmbeddingDimension(dimension int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		statement := fmt.Sprintf("ALTER TABLE document_chunks ALTER COLUMN embedding TYPE vector(%d)", dimension)
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+		if dimension > maxIndexedDimension {
+			return nil
+		}
+		return tx.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_chunk_embedding ON document_chunks USING ivfflat (embedding vector_cosine_ops) WITH (lists = %d)", embeddingIndexLists)).Error
+	})
+}
+
+// staleEmbeddings selects embedded chunks whose vector, or shadow vector, was made by another model
+func (r *chunkRepository) staleEmbeddings(embeddingModel string, next bool) *gorm.DB {
+	column := "embedding_model"
+	if next {
+		column = "embedding_next_model"
+	}
+	return r.db.Model(&model.DocumentChunk{}).
+		Where("embedding IS NOT NULL AND "+column+" IS DISTINCT FROM ?", embeddingModel)
+}
+
+func (r *chunkRepository) CountStaleEmbeddings(embeddingModel string, next bool) (int64, error) {
+	var count int64
+	err := r.staleEmbeddings(embeddingModel, next).Count(&count).Error
+	return count, err
+}
+
+// ListStaleEmbeddings pages through stale chunks by ID, loading only their ID and content
+func (r *chunkRepository) ListStaleEmbeddings(embeddingModel string, next bool, afterID, limit int) ([]*model.DocumentChunk, error) {
+	var chunks []*model.DocumentChunk
+	err := r.staleEmbeddings(embeddingModel, next).
+		Select("id", "content").
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&chunks).Error
+	return chunks, err
+}
+
+// UpdateEmbeddings stores the Embedding and EmbeddingModel of each chunk, in one transaction
+func (r *chunkRepository) UpdateEmbeddings(chunks []*model.DocumentChunk, next bool) error {
+	query := "UPDATE document_chunks SET embedding = ?::vector, embedding_model = ? WHERE id = ?"
+	if next {
+		query = "UPDATE document_chunks SET embedding_next = ?::vector, embedding_next_model = ? WHERE id = ?"
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, chunk := range chunks {
+			if err := tx.Exec(query, chunk.Embedding, chunk.EmbeddingModel, chunk.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PromoteNextEmbeddings swaps the shadow vectors in as the served embeddings,
+// changing the column to the new dimension and rebuilding the vector index.
+// The table is locked for the duration, and nothing changes unless every
+// embedded chunk has a shadow vector of embeddingModel.
+func (r *chunkRepository) PromoteNextEmbeddings(embeddingModel string, dimension int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE document_chunks IN ACCESS EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		var stale int64
+		err := tx.Model(&model.DocumentChunk{}).
+			Where("embedding IS NOT NULL AND embedding_next_model IS DISTINCT FROM ?", embeddingModel).
+			Count(&stale).Error
+		if err != nil {
+			return err
+		}
+		if stale > 0 {
+			return fmt.Errorf("%w: %d left", ErrStaleEmbeddings, stale)
+		}
+
+		statements := []string{
+			"DROP INDEX IF EXISTS idx_chunk_embedding",
+			"ALTER TABLE document_chunks DROP COLUMN embedding",
+			"ALTER TABLE document_chunks RENAME COLUMN embedding_next TO embedding",
+			fmt.Sprintf("ALTER TABLE document_chunks ALTER COLUMN embedding TYPE vector(%d)", dimension),
+			"UPDATE document_chunks SET embedding_model = embedding_next_model, embedding_next_model = NULL",
+			"ALTER TABLE document_chunks ADD COLUMN embedding_next vector",
+		}
+		if dimension <= maxIndexedDimension {
+			statements = append(statements,
+				fmt.Sprintf("CREATE INDEX idx_chunk_embedding ON document_chunks USING ivfflat (embedding vector_cosine_ops) WITH (lists = %d)", embeddingIndexLists))
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/zibianqu/eino_study/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmbeddingCacheRepository stores embeddings by model and content hash
+type EmbeddingCacheRepository interface {
+	GetByHashes(model string, hashes []string) ([]*model.EmbeddingCache, error)
+	BatchCreate(entries []*model.EmbeddingCache) error
+	Count(model string) (int64, error)
+}
+
+type embeddingCacheRepository struct {
+	db *gorm.DB
+}
+
+// NewEmbeddingCacheRepository creates a new EmbeddingCacheRepository instance
+func NewEmbeddingCacheRepository(db *gorm.DB) EmbeddingCacheRepository {
+	return &embeddingCacheRepository{db: db}
+}
+
+// GetByHashes returns the cached embeddings of a model for the given content hashes
+func (r *embeddingCacheRepository) GetByHashes(modelName string, hashes []string) ([]*model.EmbeddingCache, error) {
+	var entries []*model.EmbeddingCache
+	if len(hashes) == 0 {
+		return entries, nil
+	}
+	err := r.db.Where("model = ? AND content_hash IN ?", modelName, hashes).Find(&entries).Error
+	return entries, err
+}
+
+// BatchCreate inserts cache entries, keeping existing entries for the same key
+func (r *embeddingCacheRepository) BatchCreate(entries []*model.EmbeddingCache) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(entries, 100).Error
+}
+
+// Count returns the number of cached embeddings of a model
+func (r *embeddingCacheRepository) Count(modelName string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.EmbeddingCache{}).Where("model = ?", modelName).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"github.com/zibianqu/eino_study/internal/model"
+	"gorm.io/gorm"
+)
+
+// PromptTemplateRepository reads versioned prompt templates
+type PromptTemplateRepository interface {
+	List() ([]*model.PromptTemplate, error)
+}
+
+type promptTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewPromptTemplateRepository creates a new PromptTemplateRepository instance
+func NewPromptTemplateRepository(db *gorm.DB) PromptTemplateRepository {
+	return &promptTemplateRepository{db: db}
+}
+
+// List returns all versions of all prompt templates
+func (r *promptTemplateRepository) List() ([]*model.PromptTemplate, error) {
+	var templates []*model.PromptTemplate
+	err := r.db.Order("prompt_id, version").Find(&templates).Error
+	return templates, err
+}
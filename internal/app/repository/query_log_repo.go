@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zibianqu/eino_study/internal/model"
+	"gorm.io/gorm"
+)
+
+// Usage grouping dimensions
+const (
+	UsageByDay    = "day"
+	UsageByModel  = "model"
+	UsageByAPIKey = "api_key"
+)
+
+// usageGroupColumns are the SQL expressions of the grouping dimensions. ctime
+// holds the wall clock of the database session zone; days are UTC days, like
+// the bounds of the filter.
+var usageGroupColumns = map[string]string{
+	UsageByDay:    "to_char(ctime::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day",
+	UsageByModel:  "model",
+	UsageByAPIKey: "api_key",
+}
+
+// QueryLogFilter selects the query logs to aggregate. Zero values match all.
+type QueryLogFilter struct {
+	From    time.Time // Inclusive, days start at midnight UTC
+	To      time.Time // Exclusive
+	Model   string
+	APIKey  string
+	GroupBy []string // UsageByDay, UsageByModel and/or UsageByAPIKey
+}
+
+// UsageAggregate is the usage of one group of query logs
+type UsageAggregate struct {
+	Day              string
+	Model            string
+	APIKey           string
+	Queries          int64
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+	Cost             float64
+	AvgLatencyMs     float64
+}
+
+// QueryLogRepository stores answered queries and aggregates their usage
+type QueryLogRepository interface {
+	Create(entry *model.QueryLog) error
+	Aggregate(filter *QueryLogFilter) ([]*UsageAggregate, error)
+}
+
+type queryLogRepository struct {
+	db *gorm.DB
+}
+
+// NewQueryLogRepository creates a new QueryLogRepository instance
+func NewQueryLogRepository(db *gorm.DB) QueryLogRepository {
+	return &queryLogRepository{db: db}
+}
+
+// Create inserts a query log
+func (r *queryLogRepository) Create(entry *model.QueryLog) error {
+	return r.db.Create(entry).Error
+}
+
+// Aggregate sums the usage of the matching query logs per group, newest day first
+func (r *queryLogRepository) Aggregate(filter *QueryLogFilter) ([]*UsageAggregate, error) {
+	columns := make([]string, 0, len(filter.GroupBy)+6)
+	groups := make([]string, 0, len(filter.GroupBy))
+	order := make([]string, 0, len(filter.GroupBy))
+	for _, group := range filter.GroupBy {
+		column, ok := usageGroupColumns[group]
+		if !ok {
+			continue
+		}
+		columns = append(columns, column)
+		groups = append(groups, group)
+		if group == UsageByDay {
+			order = append(order, group+" DESC")
+		} else {
+			order = append(order, group)
+		}
+	}
+	columns = append(columns,
+		"COUNT(*) AS queries",
+		"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens",
+		"COALESCE(SUM(completion_tokens), 0) AS completion_tokens",
+		"COALESCE(SUM(total_tokens), 0) AS total_tokens",
+		"COALESCE(SUM(cost), 0) AS cost",
+		"COALESCE(AVG(latency_ms), 0) AS avg_latency_ms",
+	)
+
+	query := r.db.Model(&model.QueryLog{}).Select(strings.Join(columns, ", "))
+	// The bounds are converted to the wall clock of ctime rather than ctime
+	// to an instant, so that the index on ctime is used
+	if !filter.From.IsZero() {
+		query = query.Where("ctime >= (?::timestamptz AT TIME ZONE current_setting('TimeZone'))", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("ctime < (?::timestamptz AT TIME ZONE current_setting('TimeZone'))", filter.To)
+	}
+	if filter.Model != "" {
+		query = query.Where("model = ?", filter.Model)
+	}
+	if filter.APIKey != "" {
+		query = query.Where("api_key = ?", filter.APIKey)
+	}
+	if len(groups) > 0 {
+		query = query.Group(strings.Join(groups, ", ")).Order(strings.Join(order, ", "))
+	}
+
+	var rows []*UsageAggregate
+	err := query.Scan(&rows).Error
+	return rows, err
+}
@@ -17,6 +17,8 @@ func SetupRouter(services *service.ServiceContainer) *gin.Engine {
 	healthHandler := handler.NewHealthHandler()
 	docHandler := handler.NewDocumentHandler(services.DocumentService)
 	queryHandler := handler.NewQueryHandler(services.RAGService)
+	embeddingHandler := handler.NewEmbeddingHandler(services.EmbeddingService)
+	usageHandler := handler.NewUsageHandler(services.UsageService)
 
 	// API v1 routes
 	v1 := r.Group("/api/v1")
@@ -32,10 +34,19 @@ func SetupRouter(services *service.ServiceContainer) *gin.Engine {
 			docs.GET("/:id", docHandler.Get)
 			docs.DELETE("/:id", docHandler.Delete)
 			docs.POST("/:id/process", docHandler.Process)
+			docs.GET("/:id/chunks/:index", docHandler.GetChunk)
 		}
 
 		// Query
 		v1.POST("/query", queryHandler.Query)
+
+		// Embeddings
+		v1.GET("/embeddings/cache/stats", embeddingHandler.CacheStats)
+		v1.POST("/embeddings/reembed", embeddingHandler.StartReembed)
+		v1.GET("/embeddings/reembed", embeddingHandler.ReembedStatus)
+
+		// Usage
+		v1.GET("/usage", usageHandler.Summary)
 	}
 
 	return r
@@ -46,7 +57,7 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -10,15 +12,23 @@ import (
 	"github.com/zibianqu/eino_study/internal/eino/graph"
 	"github.com/zibianqu/eino_study/internal/model"
 	"github.com/zibianqu/eino_study/internal/pkg/utils"
+	"github.com/zibianqu/eino_study/pkg/api"
 	"gorm.io/gorm"
 )
 
+// Errors returned when a document or chunk does not exist
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrChunkNotFound    = errors.New("chunk not found")
+)
+
 type DocumentService interface {
 	UploadDocument(filePath, docName string) (*model.Document, error)
 	GetDocument(docID string) (*model.Document, error)
 	ListDocuments(page, perPage int) ([]*model.Document, int64, error)
 	DeleteDocument(docID string) error
 	ProcessDocument(docID string) error
+	GetChunk(docID string, index int) (*api.ChunkResponse, error)
 }
 
 type documentService struct {
@@ -97,7 +107,7 @@ func (s *documentService) GetDocument(docID string) (*model.Document, error) {
 	doc, err := s.docRepo.GetByID(docID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("document not found")
+			return nil, ErrDocumentNotFound
 		}
 		return nil, fmt.Errorf("failed to get document: %w", err)
 	}
@@ -156,4 +166,42 @@ func (s *documentService) ProcessDocument(docID string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// GetChunk returns a chunk of a document by index, with its location in the source file
+func (s *documentService) GetChunk(docID string, index int) (*api.ChunkResponse, error) {
+	doc, err := s.GetDocument(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk, err := s.chunkRepo.GetByDocIDAndIndex(docID, index)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrChunkNotFound
+		}
+		return nil, fmt.Errorf("failed to get chunk: %w", err)
+	}
+
+	resp := &api.ChunkResponse{
+		ID:         chunk.ID,
+		DocID:      chunk.DocID,
+		DocName:    doc.DocName,
+		ChunkIndex: chunk.ChunkIndex,
+		ChunkType:  chunk.ChunkType,
+		ParentID:   chunk.ParentID,
+		Content:    chunk.Content,
+	}
+	if chunk.Metadata != "" {
+		// The location fields share their names with the metadata keys
+		if err := json.Unmarshal([]byte(chunk.Metadata), &resp.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode chunk metadata: %w", err)
+		}
+		if err := json.Unmarshal([]byte(chunk.Metadata), &resp.Location); err != nil {
+			return nil, fmt.Errorf("failed to decode chunk location: %w", err)
+		}
+	}
+	resp.Location.FilePath = doc.FilePath
+
+	return resp, nil
+}
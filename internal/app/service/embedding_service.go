@@ -0,0 +1,48 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zibianqu/eino_study/internal/eino/embedding"
+	"github.com/zibianqu/eino_study/internal/eino/graph"
+)
+
+// EmbeddingService exposes the state of the embedding client and migrates
+// stored vectors between embedding models
+type EmbeddingService interface {
+	CacheStats() (*embedding.CacheStats, error)
+	StartReembed() (*graph.ReembedStatus, error)
+	ReembedStatus() *graph.ReembedStatus
+}
+
+type embeddingService struct {
+	embeddingClient *embedding.EmbeddingClient
+	reembedder      *graph.Reembedder
+}
+
+// NewEmbeddingService creates a new EmbeddingService instance
+func NewEmbeddingService(embeddingClient *embedding.EmbeddingClient, reembedder *graph.Reembedder) EmbeddingService {
+	return &embeddingService{
+		embeddingClient: embeddingClient,
+		reembedder:      reembedder,
+	}
+}
+
+// CacheStats returns embedding cache hit and miss statistics
+func (s *embeddingService) CacheStats() (*embedding.CacheStats, error) {
+	stats, err := s.embeddingClient.CacheStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache stats: %w", err)
+	}
+	return stats, nil
+}
+
+// StartReembed starts re-embedding stored chunks in the background
+func (s *embeddingService) StartReembed() (*graph.ReembedStatus, error) {
+	return s.reembedder.Start()
+}
+
+// ReembedStatus returns the progress of the current or last re-embedding job
+func (s *embeddingService) ReembedStatus() *graph.ReembedStatus {
+	return s.reembedder.Status()
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/zibianqu/eino_study/internal/app/repository"
 	"github.com/zibianqu/eino_study/internal/config"
@@ -9,14 +10,18 @@ import (
 	"github.com/zibianqu/eino_study/internal/eino/embedding"
 	"github.com/zibianqu/eino_study/internal/eino/graph"
 	"github.com/zibianqu/eino_study/internal/eino/loader"
+	"github.com/zibianqu/eino_study/internal/eino/prompt"
 	"github.com/zibianqu/eino_study/internal/eino/retriever"
 	"github.com/zibianqu/eino_study/internal/eino/splitter"
+	"github.com/zibianqu/eino_study/internal/eino/tokenizer"
 )
 
 // ServiceContainer holds all services and their dependencies
 type ServiceContainer struct {
-	DocumentService DocumentService
-	RAGService      RAGService
+	DocumentService  DocumentService
+	RAGService       RAGService
+	EmbeddingService EmbeddingService
+	UsageService     UsageService
 }
 
 // InitServices initializes all services with their dependencies
@@ -25,12 +30,32 @@ func InitServices(
 	docRepo repository.DocumentRepository,
 	chunkRepo repository.ChunkRepository,
 	entityRepo repository.EntityRepository,
+	embeddingCacheRepo repository.EmbeddingCacheRepository,
+	queryLogRepo repository.QueryLogRepository,
+	promptRepo repository.PromptTemplateRepository,
 ) (*ServiceContainer, error) {
 	// Initialize Eino components
 	embeddingClient, err := embedding.NewEmbeddingClient(&cfg.Eino.Embedding)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embedding client: %w", err)
 	}
+	if cfg.Eino.Embedding.Cache {
+		embeddingClient.SetCache(embeddingCacheRepo)
+	}
+	if err := validateEmbeddingStore(cfg, embeddingClient, chunkRepo); err != nil {
+		return nil, err
+	}
+
+	var migrationClient *embedding.EmbeddingClient
+	if cfg.Eino.EmbeddingMigration != nil {
+		migrationClient, err = embedding.NewEmbeddingClient(cfg.Eino.EmbeddingMigration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create migration embedding client: %w", err)
+		}
+		if cfg.Eino.EmbeddingMigration.Cache {
+			migrationClient.SetCache(embeddingCacheRepo)
+		}
+	}
 
 	chatModelClient, err := chatmodel.NewChatModelClient(&cfg.Eino.LLM)
 	if err != nil {
@@ -38,32 +63,48 @@ func InitServices(
 	}
 
 	// Initialize loader and splitter
-	loaderFactory := loader.NewLoaderFactory()
-	textSplitter := splitter.NewTextSplitter(
-		cfg.Eino.Splitter.ChunkSize,
-		cfg.Eino.Splitter.ChunkOverlap,
-	)
+	loaderFactory, err := loader.NewLoaderFactory(cfg.Eino.Loaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create loader factory: %w", err)
+	}
+	tok, err := tokenizer.New(cfg.Eino.Splitter.Tokenizer, cfg.Eino.Splitter.VocabFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tokenizer: %w", err)
+	}
+	textSplitter, err := splitter.NewSplitter(&cfg.Eino.Splitter, tok, embeddingClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create splitter: %w", err)
+	}
+	parentSplitter, err := splitter.NewParentSplitter(&cfg.Eino.Splitter, tok)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parent splitter: %w", err)
+	}
 
 	// Initialize document processor
 	docProcessor := graph.NewDocumentProcessor(
 		loaderFactory,
 		textSplitter,
+		parentSplitter,
 		embeddingClient,
 		chunkRepo,
 	)
 
 	// Initialize retriever
-	vectorRetriever := retriever.NewVectorRetriever(
-		chunkRepo,
-		embeddingClient,
-		cfg.Eino.Retriever.TopK,
-		cfg.Eino.Retriever.SimilarityThreshold,
-	)
+	docRetriever, err := retriever.NewRetriever(&cfg.Eino.Retriever, chunkRepo, embeddingClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retriever: %w", err)
+	}
+
+	// Initialize prompt templates and RAG chain
+	prompts, err := prompt.NewStore(&cfg.Eino.Prompt, promptRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
 
-	// Initialize RAG chain
 	ragChain := graph.NewRAGChain(
-		vectorRetriever,
+		docRetriever,
 		chatModelClient,
+		prompts,
 	)
 
 	// Initialize services
@@ -74,13 +115,62 @@ func InitServices(
 		docProcessor,
 	)
 
+	usageService := NewUsageService(queryLogRepo, &cfg.Usage)
+
 	ragService := NewRAGService(
 		ragChain,
 		docRepo,
+		usageService,
+	)
+
+	embeddingService := NewEmbeddingService(
+		embeddingClient,
+		graph.NewReembedder(chunkRepo, embeddingClient, migrationClient),
 	)
 
 	return &ServiceContainer{
-		DocumentService: documentService,
-		RAGService:      ragService,
+		DocumentService:  documentService,
+		RAGService:       ragService,
+		EmbeddingService: embeddingService,
+		UsageService:     usageService,
 	}, nil
-}
\ No newline at end of file
+}
+
+// validateEmbeddingStore checks that the configured dimension, the embedding
+// model and the vector column agree, so that a model change cannot silently
+// mix vectors of different models
+func validateEmbeddingStore(cfg *config.Config, client *embedding.EmbeddingClient, chunkRepo repository.ChunkRepository) error {
+	dimension := client.GetDimension()
+	if cfg.VectorDB.Dimension > 0 && cfg.VectorDB.Dimension != dimension {
+		return fmt.Errorf("vectordb dimension %d does not match embedding model %s", cfg.VectorDB.Dimension, client.Model())
+	}
+
+	column, err := chunkRepo.EmbeddingDimension()
+	if err != nil {
+		return fmt.Errorf("failed to read embedding column dimension: %w", err)
+	}
+	if column == 0 {
+		// A fresh install leaves the dimension to the configured model
+		if err := chunkRepo.SetEmbeddingDimension(dimension); err != nil {
+			return fmt.Errorf("failed to set embedding column to vector(%d): %w", dimension, err)
+		}
+		log.Printf("Set document_chunks.embedding to vector(%d) for embedding model %s", dimension, client.Model())
+		column = dimension
+	}
+	if column != dimension {
+		return fmt.Errorf(
+			"document_chunks.embedding is vector(%d) but embedding model %s returns %d dimensions; "+
+				"migrate with embedding_migration or restore the previous model",
+			column, client.Model(), dimension,
+		)
+	}
+
+	stale, err := chunkRepo.CountStaleEmbeddings(client.Model(), false)
+	if err != nil {
+		return fmt.Errorf("failed to count chunks of other embedding models: %w", err)
+	}
+	if stale > 0 {
+		log.Printf("Warning: %d chunks were embedded by another model than %s; re-embed them with POST /api/v1/embeddings/reembed", stale, client.Model())
+	}
+	return nil
+}
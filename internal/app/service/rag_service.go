@@ -2,7 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
 
 	"github.com/zibianqu/eino_study/internal/app/repository"
 	"github.com/zibianqu/eino_study/internal/eino/graph"
@@ -10,39 +16,138 @@ import (
 )
 
 type RAGService interface {
-	Query(query string, topK int) (*api.QueryResponse, error)
+	Query(ctx context.Context, req *api.QueryRequest, apiKey string) (*api.QueryResponse, error)
+	QueryStream(ctx context.Context, req *api.QueryRequest, apiKey string, send func(event string, data any) error) error
 }
 
 type ragService struct {
-	chain   *graph.RAGChain
-	docRepo repository.DocumentRepository
+	chain        *graph.RAGChain
+	docRepo      repository.DocumentRepository
+	usageService UsageService
 }
 
 func NewRAGService(
 	chain *graph.RAGChain,
 	docRepo repository.DocumentRepository,
+	usageService UsageService,
 ) RAGService {
 	return &ragService{
-		chain:   chain,
-		docRepo: docRepo,
+		chain:        chain,
+		docRepo:      docRepo,
+		usageService: usageService,
 	}
 }
 
-func (s *ragService) Query(query string, topK int) (*api.QueryResponse, error) {
-	if query == "" {
+// Query answers query from the knowledge base and records its usage under
+// the API key of the caller
+func (s *ragService) Query(ctx context.Context, req *api.QueryRequest, apiKey string) (*api.QueryResponse, error) {
+	if req.Query == "" {
 		return nil, fmt.Errorf("query is empty")
 	}
 
 	// Execute RAG chain
-	ctx := context.Background()
-	result, err := s.chain.Run(ctx, query)
+	start := time.Now()
+	result, err := s.chain.Run(ctx, ragRequest(req))
 	if err != nil {
 		return nil, fmt.Errorf("RAG query failed: %w", err)
 	}
+	usage := s.usageService.Record(apiKey, req.Query, result, time.Since(start))
+
+	return &api.QueryResponse{
+		Answer:        result.Answer,
+		Sources:       s.buildSources(result.Sources),
+		Usage:         usage,
+		Backend:       result.Backend,
+		PromptID:      result.PromptID,
+		PromptVersion: result.PromptVersion,
+	}, nil
+}
+
+// QueryStream answers query like Query, passing the answer to send as it is
+// generated: first the sources, then the deltas of the answer and finally
+// the usage. It stops when send fails, e.g. because the client went away,
+// and cancelling ctx cancels the LLM call.
+func (s *ragService) QueryStream(ctx context.Context, req *api.QueryRequest, apiKey string, send func(event string, data any) error) error {
+	if req.Query == "" {
+		return fmt.Errorf("query is empty")
+	}
+
+	start := time.Now()
+	stream, err := s.chain.RunStream(ctx, ragRequest(req))
+	if err != nil {
+		return fmt.Errorf("RAG query failed: %w", err)
+	}
+	defer stream.Close()
+
+	result := stream.Response()
+	err = send(api.QueryEventSources, &api.QuerySourcesEvent{
+		Sources:       s.buildSources(stream.Sources),
+		PromptID:      result.PromptID,
+		PromptVersion: result.PromptVersion,
+	})
+	for err == nil {
+		var delta string
+		delta, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			break
+		}
+		if err != nil {
+			err = fmt.Errorf("LLM generation failed: %w", err)
+			break
+		}
+		if delta != "" {
+			err = send(api.QueryEventDelta, &api.QueryDeltaEvent{Content: delta})
+		}
+	}
+
+	// Tokens of an interrupted answer are spent as well
+	result = stream.Response()
+	usage := s.usageService.Record(apiKey, req.Query, result, time.Since(start))
+	if err != nil {
+		return err
+	}
+	return send(api.QueryEventUsage, &api.QueryUsageEvent{
+		Usage:   usage,
+		Backend: result.Backend,
+	})
+}
+
+// ragRequest converts a query request for the RAG chain
+func ragRequest(req *api.QueryRequest) *graph.RAGRequest {
+	ragReq := &graph.RAGRequest{
+		Query:               req.Query,
+		PromptID:            req.PromptID,
+		PromptVersion:       req.PromptVersion,
+		TopK:                req.TopK,
+		SimilarityThreshold: req.SimilarityThreshold,
+	}
+
+	if f := req.Filters; f != nil {
+		filter := &repository.ChunkFilter{
+			DocIDs:   f.DocIDs,
+			FileType: f.FileType,
+			Metadata: f.Metadata,
+		}
+		if filter.FileType != "" && !strings.HasPrefix(filter.FileType, ".") {
+			filter.FileType = "." + filter.FileType
+		}
+		if f.CTimeFrom != nil {
+			filter.CTimeFrom = *f.CTimeFrom
+		}
+		if f.CTimeTo != nil {
+			filter.CTimeTo = *f.CTimeTo
+		}
+		ragReq.Filter = filter
+	}
+	return ragReq
+}
 
-	// Build response with sources
-	sources := make([]api.SourceInfo, 0, len(result.Sources))
-	for _, doc := range result.Sources {
+// buildSources describes the retrieved documents, with their names from
+// the database
+func (s *ragService) buildSources(docs []*schema.Document) []api.SourceInfo {
+	sources := make([]api.SourceInfo, 0, len(docs))
+	for _, doc := range docs {
 		// Get document metadata
 		docID := ""
 		if id, ok := doc.MetaData["doc_id"].(string); ok {
@@ -57,26 +162,14 @@ func (s *ragService) Query(query string, topK int) (*api.QueryResponse, error) {
 			}
 		}
 
+		headingPath, _ := doc.MetaData["heading_path"].(string)
+
 		sources = append(sources, api.SourceInfo{
-			DocID:   docID,
-			DocName: docName,
-			Content: doc.Content,
+			DocID:       docID,
+			DocName:     docName,
+			Content:     doc.Content,
+			HeadingPath: headingPath,
 		})
 	}
-
-	// Build usage info
-	var usage *api.UsageInfo
-	if result.Usage != nil {
-		usage = &api.UsageInfo{
-			PromptTokens:     result.Usage.PromptTokens,
-			CompletionTokens: result.Usage.CompletionTokens,
-			TotalTokens:      result.Usage.TotalTokens,
-		}
-	}
-
-	return &api.QueryResponse{
-		Answer:  result.Answer,
-		Sources: sources,
-		Usage:   usage,
-	}, nil
-}
\ No newline at end of file
+	return sources
+}
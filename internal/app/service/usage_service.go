@@ -0,0 +1,130 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/zibianqu/eino_study/internal/app/repository"
+	"github.com/zibianqu/eino_study/internal/config"
+	"github.com/zibianqu/eino_study/internal/eino/graph"
+	"github.com/zibianqu/eino_study/internal/model"
+	"github.com/zibianqu/eino_study/pkg/api"
+)
+
+// UsageService records the token usage and cost of answered queries and
+// reports it
+type UsageService interface {
+	Record(apiKey, query string, result *graph.RAGResponse, latency time.Duration) *api.UsageInfo
+	Summary(filter *repository.QueryLogFilter) (*api.UsageResponse, error)
+}
+
+type usageService struct {
+	queryLogRepo repository.QueryLogRepository
+	currency     string
+	prices       map[string]config.ModelPrice
+}
+
+// NewUsageService creates a new UsageService instance
+func NewUsageService(queryLogRepo repository.QueryLogRepository, cfg *config.UsageConfig) UsageService {
+	prices := make(map[string]config.ModelPrice, len(cfg.Prices))
+	for _, price := range cfg.Prices {
+		prices[price.Model] = price
+	}
+	return &usageService{
+		queryLogRepo: queryLogRepo,
+		currency:     cfg.Currency,
+		prices:       prices,
+	}
+}
+
+// Record stores the usage of an answered query in the query log and returns
+// it priced. Failing to store it is logged, the answer is still returned.
+func (s *usageService) Record(apiKey, query string, result *graph.RAGResponse, latency time.Duration) *api.UsageInfo {
+	usage := &api.UsageInfo{LatencyMs: latency.Milliseconds()}
+	if result.Usage != nil {
+		usage.PromptTokens = result.Usage.PromptTokens
+		usage.CompletionTokens = result.Usage.CompletionTokens
+		usage.TotalTokens = result.Usage.TotalTokens
+	}
+	usage.Cost = s.cost(result.Model, result.Backend, usage)
+
+	entry := &model.QueryLog{
+		APIKey:           apiKeyFingerprint(apiKey),
+		Query:            query,
+		Backend:          result.Backend,
+		Model:            result.Model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		LatencyMs:        usage.LatencyMs,
+		Cost:             usage.Cost,
+	}
+	if err := s.queryLogRepo.Create(entry); err != nil {
+		log.Printf("Warning: failed to write query log: %v", err)
+	}
+	return usage
+}
+
+// Summary aggregates the query log, with the total of all rows
+func (s *usageService) Summary(filter *repository.QueryLogFilter) (*api.UsageResponse, error) {
+	aggregates, err := s.queryLogRepo.Aggregate(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate query log: %w", err)
+	}
+
+	resp := &api.UsageResponse{
+		Currency: s.currency,
+		Rows:     make([]api.UsageRow, 0, len(aggregates)),
+	}
+	var latency float64
+	for _, agg := range aggregates {
+		resp.Rows = append(resp.Rows, api.UsageRow{
+			Day:              agg.Day,
+			Model:            agg.Model,
+			APIKey:           agg.APIKey,
+			Queries:          agg.Queries,
+			PromptTokens:     agg.PromptTokens,
+			CompletionTokens: agg.CompletionTokens,
+			TotalTokens:      agg.TotalTokens,
+			Cost:             agg.Cost,
+			AvgLatencyMs:     agg.AvgLatencyMs,
+		})
+		resp.Total.Queries += agg.Queries
+		resp.Total.PromptTokens += agg.PromptTokens
+		resp.Total.CompletionTokens += agg.CompletionTokens
+		resp.Total.TotalTokens += agg.TotalTokens
+		resp.Total.Cost += agg.Cost
+		latency += agg.AvgLatencyMs * float64(agg.Queries)
+	}
+	if resp.Total.Queries > 0 {
+		resp.Total.AvgLatencyMs = latency / float64(resp.Total.Queries)
+	}
+	return resp, nil
+}
+
+// cost estimates the cost of usage from the price of the model, or of the
+// backend when the model has no price
+func (s *usageService) cost(modelName, backend string, usage *api.UsageInfo) float64 {
+	price, ok := s.prices[modelName]
+	if !ok {
+		price, ok = s.prices[backend]
+	}
+	if !ok {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.PromptPrice +
+		float64(usage.CompletionTokens)*price.CompletionPrice) / 1e6
+}
+
+// apiKeyFingerprint identifies an API key in the query log without storing
+// it: the first 16 hex digits of its SHA-256
+func apiKeyFingerprint(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])[:16]
+}
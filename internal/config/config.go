@@ -15,6 +15,7 @@ type Config struct {
 	Neo4j    Neo4jConfig    `mapstructure:"neo4j"`
 	VectorDB VectorDBConfig `mapstructure:"vectordb"`
 	Eino     EinoConfig     `mapstructure:"eino"`
+	Usage    UsageConfig    `mapstructure:"usage"`
 	Log      LogConfig      `mapstructure:"log"`
 }
 
@@ -58,32 +59,128 @@ type EinoConfig struct {
 	Embedding EmbeddingConfig `mapstructure:"embedding"`
 	Splitter  SplitterConfig  `mapstructure:"splitter"`
 	Retriever RetrieverConfig `mapstructure:"retriever"`
+	Prompt    PromptConfig    `mapstructure:"prompt"`
+	Loaders   []LoaderConfig  `mapstructure:"loaders"`
+
+	// EmbeddingMigration is the model the re-embedding job migrates stored
+	// chunks to. Once it is promoted, move it to embedding before restarting.
+	EmbeddingMigration *EmbeddingConfig `mapstructure:"embedding_migration"`
 }
 
 type LLMConfig struct {
-	Provider    string  `mapstructure:"provider"`
-	APIKey      string  `mapstructure:"api_key"`
-	BaseURL     string  `mapstructure:"base_url"`
-	Model       string  `mapstructure:"model"`
-	Temperature float64 `mapstructure:"temperature"`
-	MaxTokens   int     `mapstructure:"max_tokens"`
+	Name        string        `mapstructure:"name"`     // Reported as the backend of answers, default provider/model
+	Provider    string        `mapstructure:"provider"` // openai, azure, anthropic, ollama, deepseek, qwen, openai_compatible or fake
+	APIKey      string        `mapstructure:"api_key"`
+	BaseURL     string        `mapstructure:"base_url"`    // Defaults per provider; required for azure and openai_compatible
+	APIVersion  string        `mapstructure:"api_version"` // Azure API version, or the anthropic-version header
+	Model       string        `mapstructure:"model"`       // Deployment name for azure
+	Temperature float64       `mapstructure:"temperature"`
+	MaxTokens   int           `mapstructure:"max_tokens"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+
+	// FakeResponses are the replies of the fake provider, returned in turn.
+	// Without any, it replies with a summary of the prompt.
+	FakeResponses []string `mapstructure:"fake_responses"`
+
+	// Failover: transient failures are retried with exponential backoff, and
+	// a backend that keeps failing is skipped for a cooldown while requests
+	// fall through to the fallbacks in order. Fallbacks use the retry and
+	// breaker settings of the top level.
+	Fallbacks        []LLMConfig   `mapstructure:"fallbacks"`
+	MaxRetries       int           `mapstructure:"max_retries"`       // Retries per backend (default 2, -1 disables)
+	RetryInterval    time.Duration `mapstructure:"retry_interval"`    // First backoff, doubled per retry (default 500ms)
+	BreakerThreshold int           `mapstructure:"breaker_threshold"` // Consecutive failures that open the breaker (default 5, -1 disables)
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown"`  // Time a backend is skipped before it is probed again (default 30s)
 }
 
 type EmbeddingConfig struct {
-	Provider  string `mapstructure:"provider"`
-	APIKey    string `mapstructure:"api_key"`
-	Model     string `mapstructure:"model"`
-	Dimension int    `mapstructure:"dimension"`
+	Provider   string `mapstructure:"provider"` // openai, azure, ollama, openai_compatible or hash (offline)
+	APIKey     string `mapstructure:"api_key"`
+	BaseURL    string `mapstructure:"base_url"`    // Endpoint for azure, ollama and openai_compatible
+	APIVersion string `mapstructure:"api_version"` // Azure API version
+	Model      string `mapstructure:"model"`       // Deployment name for azure
+	Dimension  int    `mapstructure:"dimension"`
+
+	// Requests: texts are sent in batches, several at a time, and failed
+	// batches are retried with exponential backoff when the error is transient
+	BatchSize     int           `mapstructure:"batch_size"`     // Texts per request (default 64)
+	Concurrency   int           `mapstructure:"concurrency"`    // Parallel requests (default 4)
+	MaxRetries    int           `mapstructure:"max_retries"`    // Retries per batch (default 3, -1 disables)
+	RetryInterval time.Duration `mapstructure:"retry_interval"` // First backoff, doubled per retry (default 500ms)
+
+	// Cache reuses embeddings of identical text from the embedding_cache table
+	Cache bool `mapstructure:"cache"`
+}
+
+// LoaderConfig maps file extensions or MIME types to a registered loader.
+// Rules listed later take precedence over earlier ones and over the built-in loaders.
+type LoaderConfig struct {
+	Loader     string         `mapstructure:"loader"`     // Registered loader name (text, markdown, html, pdf, ...)
+	Extensions []string       `mapstructure:"extensions"` // File extensions, e.g. [".md", ".mdx"]
+	MimeTypes  []string       `mapstructure:"mime_types"` // Sniffed MIME types, e.g. ["text/html"]
+	Options    map[string]any `mapstructure:"options"`    // Loader specific options
 }
 
 type SplitterConfig struct {
-	ChunkSize    int `mapstructure:"chunk_size"`
-	ChunkOverlap int `mapstructure:"chunk_overlap"`
+	Type         string `mapstructure:"type"`          // text (default), recursive or semantic
+	ChunkSize    int    `mapstructure:"chunk_size"`    // Chunk size in tokenizer units, the maximum for semantic
+	ChunkOverlap int    `mapstructure:"chunk_overlap"` // Overlap in tokenizer units
+	Tokenizer    string `mapstructure:"tokenizer"`     // runes (default), cl100k_base, p50k_base, r50k_base
+	VocabFile    string `mapstructure:"vocab_file"`    // tiktoken vocabulary file, default the one built in by make tokenizers
+
+	// Parent-child chunking: when set, chunks of chunk_size are embedded and
+	// searched, but their parent chunk of this size is returned to the LLM
+	ParentChunkSize int `mapstructure:"parent_chunk_size"`
+
+	// Semantic splitter
+	BreakpointPercentile float64 `mapstructure:"breakpoint_percentile"` // Cut where sentence distance exceeds this percentile (default 95)
+	MinChunkSize         int     `mapstructure:"min_chunk_size"`        // Smaller groups are merged into their neighbour
 }
 
 type RetrieverConfig struct {
+	Type                string  `mapstructure:"type"` // vector (default) or hybrid
 	TopK                int     `mapstructure:"top_k"`
-	SimilarityThreshold float64 `mapstructure:"similarity_threshold"`
+	SimilarityThreshold float64 `mapstructure:"similarity_threshold"` // Applies to vector search only
+
+	// Hybrid retrieval fuses the vector and full-text search rankings with
+	// reciprocal rank fusion: a chunk scores weight / (rrf_k + rank) per
+	// list. A weight of 0 leaves that search out.
+	VectorWeight  *float64 `mapstructure:"vector_weight"`  // Default 1
+	LexicalWeight *float64 `mapstructure:"lexical_weight"` // Default 1
+	RRFK          int      `mapstructure:"rrf_k"`          // Default 60, larger flattens the rank differences
+}
+
+// UsageConfig prices the tokens recorded in the query log
+type UsageConfig struct {
+	Currency string       `mapstructure:"currency"` // Reported with costs, e.g. USD
+	Prices   []ModelPrice `mapstructure:"prices"`
+}
+
+// ModelPrice is the price of a model per million tokens. Queries answered by
+// models without a price are logged at zero cost.
+type ModelPrice struct {
+	Model           string  `mapstructure:"model"`            // Model name, or the name of an LLM backend
+	PromptPrice     float64 `mapstructure:"prompt_price"`     // Per million prompt tokens
+	CompletionPrice float64 `mapstructure:"completion_price"` // Per million completion tokens
+}
+
+// PromptConfig selects and defines the prompts of the RAG chain. Templates
+// are also read from the prompt_templates table.
+type PromptConfig struct {
+	Default        string                 `mapstructure:"default"`         // Prompt used when a query sets no prompt_id (default "default")
+	ReloadInterval time.Duration          `mapstructure:"reload_interval"` // How often database templates are re-read (default 1m, negative disables)
+	Templates      []PromptTemplateConfig `mapstructure:"templates"`
+}
+
+// PromptTemplateConfig is one version of a named prompt
+type PromptTemplateConfig struct {
+	ID       string `mapstructure:"id"`
+	Version  int    `mapstructure:"version"`
+	Format   string `mapstructure:"format"`    // fstring (default), go_template or jinja2
+	System   string `mapstructure:"system"`    // System message
+	User     string `mapstructure:"user"`      // User message with {context} and {query}
+	Document string `mapstructure:"document"`  // Renders each retrieved document into {context}
+	NoAnswer string `mapstructure:"no_answer"` // Reply when no document was retrieved
 }
 
 type LogConfig struct {
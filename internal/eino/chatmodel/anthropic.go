@@ -0,0 +1,286 @@
+package chatmodel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/zibianqu/eino_study/internal/config"
+)
+
+// Anthropic defaults
+const (
+	DefaultAnthropicBaseURL   = "https://api.anthropic.com"
+	DefaultAnthropicVersion   = "2023-06-01"
+	defaultAnthropicMaxTokens = 1024
+)
+
+// AnthropicChatModel calls the Anthropic Messages API
+type AnthropicChatModel struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	version string
+	model   string
+}
+
+var _ model.BaseChatModel = (*AnthropicChatModel)(nil)
+
+// NewAnthropicChatModel creates an Anthropic chat model. cfg.APIVersion sets
+// the anthropic-version header.
+func NewAnthropicChatModel(cfg *config.LLMConfig) (*AnthropicChatModel, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("anthropic LLM provider requires api_key")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("anthropic LLM provider requires model")
+	}
+
+	m := &AnthropicChatModel{
+		client:  &http.Client{Timeout: cfg.Timeout},
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiKey:  cfg.APIKey,
+		version: cfg.APIVersion,
+		model:   cfg.Model,
+	}
+	if m.baseURL == "" {
+		m.baseURL = DefaultAnthropicBaseURL
+	}
+	if m.version == "" {
+		m.version = DefaultAnthropicVersion
+	}
+	return m, nil
+}
+
+// AnthropicError is an error response of the Anthropic API
+type AnthropicError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *AnthropicError) Error() string {
+	return fmt.Sprintf("anthropic error, status code: %d, type: %s, message: %s", e.StatusCode, e.Type, e.Message)
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float32           `json:"temperature,omitempty"`
+	TopP          *float32           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      anthropicUsage `json:"usage"`
+}
+
+// anthropicEvent is a server-sent event of a streamed response
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Generate sends the messages and returns the whole reply
+func (m *AnthropicChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	resp, err := m.send(ctx, input, false, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+
+	var content strings.Builder
+	for _, block := range body.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	return &schema.Message{
+		Role:         schema.Assistant,
+		Content:      content.String(),
+		ResponseMeta: anthropicResponseMeta(body.StopReason, body.Usage),
+	}, nil
+}
+
+// Stream sends the messages and streams the reply. The last message carries
+// the finish reason and token usage.
+func (m *AnthropicChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	resp, err := m.send(ctx, input, true, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sr, sw := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer sw.Close()
+		defer resp.Body.Close()
+
+		var usage anthropicUsage
+		var stopReason string
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+
+			var event anthropicEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+				sw.Send(nil, fmt.Errorf("failed to decode anthropic event: %w", err))
+				return
+			}
+			switch event.Type {
+			case "message_start":
+				usage.InputTokens = event.Message.Usage.InputTokens
+			case "content_block_delta":
+				if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+					if closed := sw.Send(&schema.Message{Role: schema.Assistant, Content: event.Delta.Text}, nil); closed {
+						return
+					}
+				}
+			case "message_delta":
+				stopReason = event.Delta.StopReason
+				usage.OutputTokens = event.Usage.OutputTokens
+			case "message_stop":
+				sw.Send(&schema.Message{
+					Role:         schema.Assistant,
+					ResponseMeta: anthropicResponseMeta(stopReason, usage),
+				}, nil)
+				return
+			case "error":
+				sw.Send(nil, &AnthropicError{StatusCode: resp.StatusCode, Type: event.Error.Type, Message: event.Error.Message})
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			sw.Send(nil, fmt.Errorf("failed to read anthropic stream: %w", err))
+			return
+		}
+		sw.Send(nil, io.ErrUnexpectedEOF)
+	}()
+
+	return sr, nil
+}
+
+// send posts a Messages API request and returns the response if it succeeded
+func (m *AnthropicChatModel) send(ctx context.Context, input []*schema.Message, stream bool, opts []model.Option) (*http.Response, error) {
+	maxTokens := defaultAnthropicMaxTokens
+	options := model.GetCommonOptions(&model.Options{Model: &m.model, MaxTokens: &maxTokens}, opts...)
+
+	req := anthropicRequest{
+		Model:         *options.Model,
+		Temperature:   options.Temperature,
+		TopP:          options.TopP,
+		StopSequences: options.Stop,
+		Stream:        stream,
+	}
+	if options.MaxTokens != nil && *options.MaxTokens > 0 {
+		req.MaxTokens = *options.MaxTokens
+	} else {
+		req.MaxTokens = defaultAnthropicMaxTokens
+	}
+
+	var system []string
+	for _, msg := range input {
+		switch msg.Role {
+		case schema.System:
+			system = append(system, msg.Content)
+		case schema.User, schema.Assistant:
+			// Consecutive messages of one role are merged, as roles must alternate
+			if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == string(msg.Role) {
+				req.Messages[n-1].Content += "\n\n" + msg.Content
+				continue
+			}
+			req.Messages = append(req.Messages, anthropicMessage{Role: string(msg.Role), Content: msg.Content})
+		default:
+			return nil, fmt.Errorf("anthropic chat model does not support %s messages", msg.Role)
+		}
+	}
+	req.System = strings.Join(system, "\n\n")
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("anthropic chat model needs at least one user message")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode anthropic request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create anthropic request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", m.apiKey)
+	httpReq.Header.Set("anthropic-version", m.version)
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		apiErr := &AnthropicError{StatusCode: resp.StatusCode, Message: resp.Status}
+		var errBody anthropicEvent
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &errBody) == nil && errBody.Error.Message != "" {
+			apiErr.Type = errBody.Error.Type
+			apiErr.Message = errBody.Error.Message
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+func anthropicResponseMeta(stopReason string, usage anthropicUsage) *schema.ResponseMeta {
+	return &schema.ResponseMeta{
+		FinishReason: stopReason,
+		Usage: &schema.TokenUsage{
+			PromptTokens:     usage.InputTokens,
+			CompletionTokens: usage.OutputTokens,
+			TotalTokens:      usage.InputTokens + usage.OutputTokens,
+		},
+	}
+}
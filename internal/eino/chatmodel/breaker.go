@@ -0,0 +1,76 @@
+package chatmodel
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker stops sending requests to a backend after threshold
+// consecutive transient failures. Once cooldown has passed, a single probe
+// request is let through: it closes the breaker if it succeeds and opens it
+// for another cooldown if it fails.
+type circuitBreaker struct {
+	threshold int // Consecutive failures that open the breaker, <= 0 disables it
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request may be sent to the backend
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success closes the breaker
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// failure counts a transient failure and reports whether it opened the
+// breaker, either by reaching the threshold or by failing the probe
+func (b *circuitBreaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 {
+		return false
+	}
+	b.failures++
+	wasProbing := b.probing
+	b.probing = false
+	if b.failures < b.threshold {
+		return false
+	}
+	b.openUntil = time.Now().Add(b.cooldown)
+	return wasProbing || b.failures == b.threshold
+}
+
+// release ends a probe that neither succeeded nor failed, e.g. because the
+// caller gave up, so that the next request probes again
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
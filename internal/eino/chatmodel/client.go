@@ -2,7 +2,9 @@ package chatmodel
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino/components/model"
@@ -10,81 +12,314 @@ import (
 	"github.com/zibianqu/eino_study/internal/config"
 )
 
-// ChatModelClient wraps Eino chat model component
+// LLM providers
+const (
+	ProviderOpenAI           = "openai"
+	ProviderAzure            = "azure"
+	ProviderAnthropic        = "anthropic"
+	ProviderOllama           = "ollama"
+	ProviderDeepSeek         = "deepseek"
+	ProviderQwen             = "qwen"
+	ProviderOpenAICompatible = "openai_compatible"
+	ProviderFake             = "fake"
+)
+
+// defaultBaseURLs are the endpoints of providers that speak the OpenAI API
+var defaultBaseURLs = map[string]string{
+	ProviderOllama:   "http://localhost:11434/v1",
+	ProviderDeepSeek: "https://api.deepseek.com/v1",
+	ProviderQwen:     "https://dashscope.aliyuncs.com/compatible-mode/v1",
+}
+
+// Defaults of the failover settings
+const (
+	DefaultMaxRetries       = 2
+	DefaultRetryInterval    = 500 * time.Millisecond
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// Keys in the Extra of generated messages, see Backend and ModelName
+const (
+	ExtraBackend = "backend" // Name of the backend that produced the message
+	ExtraModel   = "model"   // Model of that backend
+)
+
+// ErrUnavailable is returned when no backend could serve a request because
+// all of them failed transiently or have an open circuit breaker
+var ErrUnavailable = errors.New("no LLM backend available")
+
+// errCircuitOpen marks a backend skipped because of its circuit breaker
+var errCircuitOpen = errors.New("circuit breaker open")
+
+// ChatModelClient wraps Eino chat model components. Requests go to the first
+// backend whose circuit breaker is closed, and fall through to the next one
+// when it keeps failing.
 type ChatModelClient struct {
-	model       model.ChatModel
+	backends      []*backend
+	maxRetries    int
+	retryInterval time.Duration
+}
+
+// backend is one configured chat model
+type backend struct {
+	name        string
+	modelName   string
+	model       model.BaseChatModel
 	temperature float64
 	maxTokens   int
+	breaker     *circuitBreaker
 }
 
-// NewChatModelClient creates a new chat model client
+// NewChatModelClient creates a new chat model client for cfg and its fallbacks
 func NewChatModelClient(cfg *config.LLMConfig) (*ChatModelClient, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("LLM config is nil")
 	}
 
-	var chatModel model.ChatModel
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	retryInterval := cfg.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = DefaultRetryInterval
+	}
+	threshold := cfg.BreakerThreshold
+	if threshold == 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	cooldown := cfg.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+
+	client := &ChatModelClient{
+		maxRetries:    maxRetries,
+		retryInterval: retryInterval,
+	}
+	names := make(map[string]bool)
+	for _, backendCfg := range append([]config.LLMConfig{*cfg}, cfg.Fallbacks...) {
+		name := backendName(&backendCfg)
+		if names[name] {
+			return nil, fmt.Errorf("duplicate LLM backend %s, set a unique name", name)
+		}
+		names[name] = true
+
+		chatModel, err := newChatModel(&backendCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create LLM backend %s: %w", name, err)
+		}
+		client.backends = append(client.backends, &backend{
+			name:        name,
+			modelName:   backendCfg.Model,
+			model:       chatModel,
+			temperature: backendCfg.Temperature,
+			maxTokens:   backendCfg.MaxTokens,
+			breaker:     newCircuitBreaker(threshold, cooldown),
+		})
+	}
+
+	return client, nil
+}
+
+// backendName returns the configured name of a backend, or provider/model
+func backendName(cfg *config.LLMConfig) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	return cfg.Provider + "/" + cfg.Model
+}
+
+// newChatModel creates the chat model of one backend
+func newChatModel(cfg *config.LLMConfig) (model.BaseChatModel, error) {
+	var chatModel model.BaseChatModel
 	var err error
 
 	switch cfg.Provider {
-	case "openai":
+	case ProviderOpenAI:
 		chatModel, err = openai.NewChatModel(context.Background(), &openai.ChatModelConfig{
 			APIKey:  cfg.APIKey,
 			BaseURL: cfg.BaseURL,
 			Model:   cfg.Model,
+			Timeout: cfg.Timeout,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create OpenAI chat model: %w", err)
 		}
+	case ProviderAzure:
+		if cfg.BaseURL == "" || cfg.APIVersion == "" {
+			return nil, fmt.Errorf("azure LLM provider requires base_url and api_version")
+		}
+		// Model is the name of the Azure deployment, used as is
+		chatModel, err = openai.NewChatModel(context.Background(), &openai.ChatModelConfig{
+			ByAzure:              true,
+			APIKey:               cfg.APIKey,
+			BaseURL:              cfg.BaseURL,
+			APIVersion:           cfg.APIVersion,
+			Model:                cfg.Model,
+			AzureModelMapperFunc: func(model string) string { return model },
+			Timeout:              cfg.Timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure OpenAI chat model: %w", err)
+		}
+	case ProviderOllama, ProviderDeepSeek, ProviderQwen, ProviderOpenAICompatible:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = defaultBaseURLs[cfg.Provider]
+		}
+		if baseURL == "" {
+			return nil, fmt.Errorf("%s LLM provider requires base_url", cfg.Provider)
+		}
+		// Local servers usually ignore the key, but the client always sends one
+		apiKey := cfg.APIKey
+		if apiKey == "" {
+			if cfg.Provider != ProviderOllama {
+				return nil, fmt.Errorf("%s LLM provider requires api_key", cfg.Provider)
+			}
+			apiKey = ProviderOllama
+		}
+		chatModel, err = openai.NewChatModel(context.Background(), &openai.ChatModelConfig{
+			APIKey:  apiKey,
+			BaseURL: baseURL,
+			Model:   cfg.Model,
+			Timeout: cfg.Timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s chat model: %w", cfg.Provider, err)
+		}
+	case ProviderAnthropic:
+		chatModel, err = NewAnthropicChatModel(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Anthropic chat model: %w", err)
+		}
+	case ProviderFake:
+		chatModel = NewFakeChatModel(cfg.FakeResponses)
 	default:
 		return nil, fmt.Errorf("unsupported LLM provider: %s", cfg.Provider)
 	}
 
-	return &ChatModelClient{
-		model:       chatModel,
-		temperature: cfg.Temperature,
-		maxTokens:   cfg.MaxTokens,
-	}, nil
+	return chatModel, nil
+}
+
+// Backend returns the name of the backend that produced msg, the first
+// chunk of a stream carries it
+func Backend(msg *schema.Message) string {
+	if msg == nil {
+		return ""
+	}
+	name, _ := msg.Extra[ExtraBackend].(string)
+	return name
+}
+
+// ModelName returns the model that produced msg, the first chunk of a
+// stream carries it
+func ModelName(msg *schema.Message) string {
+	if msg == nil {
+		return ""
+	}
+	name, _ := msg.Extra[ExtraModel].(string)
+	return name
 }
 
-// Generate generates a response from the chat model
+// Generate generates a response from the first available backend
 func (c *ChatModelClient) Generate(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.Message, error) {
 	if len(messages) == 0 {
 		return nil, fmt.Errorf("messages is empty")
 	}
 
-	// Add default options
-	options := []model.Option{
-		model.WithTemperature(c.temperature),
-		model.WithMaxTokens(c.maxTokens),
-	}
-	options = append(options, opts...)
+	var errs []error
+	for _, b := range c.backends {
+		if !b.breaker.allow() {
+			errs = append(errs, fmt.Errorf("%s: %w", b.name, errCircuitOpen))
+			continue
+		}
 
-	response, err := c.model.Generate(ctx, messages, options...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate response: %w", err)
+		response, err := callWithRetry(ctx, c, b, func() (*schema.Message, error) {
+			return b.model.Generate(ctx, messages, b.options(opts)...)
+		})
+		if err == nil {
+			setBackend(response, b)
+			return response, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.name, err))
+		if ctx.Err() != nil {
+			break
+		}
 	}
 
-	return response, nil
+	return nil, c.failoverError(ctx, "failed to generate response", errs)
 }
 
-// GenerateStream generates a streaming response from the chat model
+// GenerateStream generates a streaming response from the first available
+// backend. Only failures to open the stream fall through to the next backend.
 func (c *ChatModelClient) GenerateStream(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
 	if len(messages) == 0 {
 		return nil, fmt.Errorf("messages is empty")
 	}
 
-	// Add default options
+	var errs []error
+	for _, b := range c.backends {
+		if !b.breaker.allow() {
+			errs = append(errs, fmt.Errorf("%s: %w", b.name, errCircuitOpen))
+			continue
+		}
+
+		stream, err := callWithRetry(ctx, c, b, func() (*schema.StreamReader[*schema.Message], error) {
+			return b.model.Stream(ctx, messages, b.options(opts)...)
+		})
+		if err == nil {
+			first := true
+			return schema.StreamReaderWithConvert(stream, func(msg *schema.Message) (*schema.Message, error) {
+				if first && msg != nil {
+					first = false
+					setBackend(msg, b)
+				}
+				return msg, nil
+			}), nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.name, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, c.failoverError(ctx, "failed to generate streaming response", errs)
+}
+
+// options returns the default options of the backend followed by opts
+func (b *backend) options(opts []model.Option) []model.Option {
 	options := []model.Option{
-		model.WithTemperature(c.temperature),
-		model.WithMaxTokens(c.maxTokens),
+		model.WithTemperature(float32(b.temperature)),
+		model.WithMaxTokens(b.maxTokens),
 	}
-	options = append(options, opts...)
+	return append(options, opts...)
+}
 
-	stream, err := c.model.Stream(ctx, messages, options...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate streaming response: %w", err)
+// failoverError combines the errors of all backends tried, wrapping
+// ErrUnavailable when none of them refused the request itself
+func (c *ChatModelClient) failoverError(ctx context.Context, msg string, errs []error) error {
+	err := errors.Join(errs...)
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	for _, backendErr := range errs {
+		if !errors.Is(backendErr, errCircuitOpen) && !isRetryable(ctx, backendErr) {
+			return fmt.Errorf("%s: %w", msg, err)
+		}
 	}
+	return fmt.Errorf("%s: %w: %w", msg, ErrUnavailable, err)
+}
 
-	return stream, nil
-}
\ No newline at end of file
+// setBackend records the backend and its model in the Extra of msg
+func setBackend(msg *schema.Message, b *backend) {
+	if msg == nil {
+		return
+	}
+	if msg.Extra == nil {
+		msg.Extra = make(map[string]any)
+	}
+	msg.Extra[ExtraBackend] = b.name
+	msg.Extra[ExtraModel] = b.modelName
+}
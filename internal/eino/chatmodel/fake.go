@@ -0,0 +1,105 @@
+package chatmodel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// FakeChatModel is a scripted chat model for local development and CI. It
+// returns its responses in turn, starting over after the last, and without
+// any replies with a summary of the prompt. Token usage is estimated.
+type FakeChatModel struct {
+	responses []string
+	next      atomic.Int64
+}
+
+var _ model.BaseChatModel = (*FakeChatModel)(nil)
+
+// NewFakeChatModel creates a fake chat model replying with responses in turn
+func NewFakeChatModel(responses []string) *FakeChatModel {
+	return &FakeChatModel{responses: responses}
+}
+
+// Generate returns the next scripted reply
+func (m *FakeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	reply := m.reply(input)
+	return &schema.Message{
+		Role:         schema.Assistant,
+		Content:      reply,
+		ResponseMeta: fakeResponseMeta(input, reply),
+	}, nil
+}
+
+// Stream returns the next scripted reply word by word
+func (m *FakeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	reply := m.reply(input)
+	sr, sw := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer sw.Close()
+		for _, word := range strings.SplitAfter(reply, " ") {
+			if err := ctx.Err(); err != nil {
+				sw.Send(nil, err)
+				return
+			}
+			if closed := sw.Send(&schema.Message{Role: schema.Assistant, Content: word}, nil); closed {
+				return
+			}
+		}
+		sw.Send(&schema.Message{Role: schema.Assistant, ResponseMeta: fakeResponseMeta(input, reply)}, nil)
+	}()
+	return sr, nil
+}
+
+func (m *FakeChatModel) reply(input []*schema.Message) string {
+	if len(m.responses) > 0 {
+		i := m.next.Add(1) - 1
+		return m.responses[i%int64(len(m.responses))]
+	}
+
+	var last string
+	for i := len(input) - 1; i >= 0; i-- {
+		if input[i].Role == schema.User {
+			last = input[i].Content
+			break
+		}
+	}
+	if runes := []rune(last); len(runes) > 200 {
+		last = string(runes[:200]) + "..."
+	}
+	return fmt.Sprintf("[fake] Received %d messages. Last question: %s", len(input), last)
+}
+
+// fakeResponseMeta estimates token usage at four characters per token
+func fakeResponseMeta(input []*schema.Message, reply string) *schema.ResponseMeta {
+	prompt := 0
+	for _, msg := range input {
+		prompt += estimateTokens(msg.Content)
+	}
+	completion := estimateTokens(reply)
+	return &schema.ResponseMeta{
+		FinishReason: "stop",
+		Usage: &schema.TokenUsage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		},
+	}
+}
+
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
@@ -0,0 +1,97 @@
+package chatmodel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	goopenai "github.com/meguminnnnnnnnn/go-openai"
+)
+
+// maxRetryInterval caps the exponential backoff between retries
+const maxRetryInterval = 10 * time.Second
+
+// callWithRetry calls one backend, retrying transient failures with
+// exponential backoff and jitter while its circuit breaker stays closed
+func callWithRetry[T any](ctx context.Context, c *ChatModelClient, b *backend, call func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		result, err := call()
+		if err == nil {
+			b.breaker.success()
+			return result, nil
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			b.breaker.release()
+			return result, err
+		case !isRetryable(ctx, err):
+			// The backend answered, the request itself was refused
+			b.breaker.success()
+			return result, err
+		}
+		if b.breaker.failure() {
+			log.Printf("Warning: LLM backend %s is failing, skipping it for %s: %v", b.name, b.breaker.cooldown, err)
+		}
+		if attempt >= c.maxRetries || !b.breaker.allow() {
+			return result, err
+		}
+
+		timer := time.NewTimer(backoff(c.retryInterval, attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			b.breaker.release()
+			return result, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait before retry number attempt+1: the interval
+// doubled per attempt, of which the upper half is random
+func backoff(interval time.Duration, attempt int) time.Duration {
+	d := interval
+	for i := 0; i < attempt && d < maxRetryInterval; i++ {
+		d *= 2
+	}
+	d = min(d, maxRetryInterval)
+	return d/2 + rand.N(d/2+1)
+}
+
+// isRetryable reports whether a failed request may succeed if sent again:
+// rate limits, overloaded or failing servers, timeouts and dropped connections
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *goopenai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode > 0 {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *goopenai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode > 0 {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+	var anthropicErr *AnthropicError
+	if errors.As(err, &anthropicErr) && anthropicErr.StatusCode > 0 {
+		return retryableStatus(anthropicErr.StatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests ||
+		code == http.StatusRequestTimeout ||
+		code >= http.StatusInternalServerError
+}
@@ -0,0 +1,178 @@
+package embedding
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/zibianqu/eino_study/internal/app/repository"
+	"github.com/zibianqu/eino_study/internal/model"
+)
+
+// cacheLookupSize bounds the number of hashes per cache query
+const cacheLookupSize = 500
+
+// embeddingCache looks up embeddings by content hash before they are
+// requested from the provider, and counts hits and misses
+type embeddingCache struct {
+	repo   repository.EmbeddingCacheRepository
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// CacheStats reports how often embeddings were served from the cache
+type CacheStats struct {
+	Enabled bool    `json:"enabled"`
+	Model   string  `json:"model,omitempty"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	Entries int64   `json:"entries"`
+}
+
+// SetCache makes the client look up embeddings in repo before calling the
+// provider and store the ones it had to request. Entries are keyed by
+// provider, model and dimension, so switching models never reuses vectors.
+func (c *EmbeddingClient) SetCache(repo repository.EmbeddingCacheRepository) {
+	c.cache = &embeddingCache{repo: repo}
+}
+
+// CacheStats returns hit and miss counts since startup and the number of
+// cached embeddings of the current model
+func (c *EmbeddingClient) CacheStats() (*CacheStats, error) {
+	if c.cache == nil {
+		return &CacheStats{}, nil
+	}
+
+	model := c.Model()
+	stats := &CacheStats{
+		Enabled: true,
+		Model:   model,
+		Hits:    c.cache.hits.Load(),
+		Misses:  c.cache.misses.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	entries, err := c.cache.repo.Count(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count cached embeddings: %w", err)
+	}
+	stats.Entries = entries
+	return stats, nil
+}
+
+// lookup fills result with cached embeddings and returns the indexes of the
+// texts still to embed. Repeated texts are embedded once: the indexes of
+// their copies are returned in dups, keyed by the index that gets embedded.
+// A failing cache is logged and bypassed rather than failing ingestion.
+func (e *embeddingCache) lookup(modelName string, texts []string, result [][]float32) (hashes []string, missing []int, dups map[int][]int) {
+	hashes = make([]string, len(texts))
+	first := make(map[string]int, len(texts))
+	dups = make(map[int][]int)
+	var unique []string
+	for i, text := range texts {
+		hashes[i] = contentHash(text)
+		if j, ok := first[hashes[i]]; ok {
+			dups[j] = append(dups[j], i)
+			continue
+		}
+		first[hashes[i]] = i
+		unique = append(unique, hashes[i])
+	}
+
+	cached := make(map[string][]float32, len(unique))
+	for start := 0; start < len(unique); start += cacheLookupSize {
+		entries, err := e.repo.GetByHashes(modelName, unique[start:min(start+cacheLookupSize, len(unique))])
+		if err != nil {
+			log.Printf("Warning: embedding cache lookup failed: %v", err)
+			break
+		}
+		for _, entry := range entries {
+			vector, err := parseVector(entry.Embedding)
+			if err != nil {
+				log.Printf("Warning: ignoring cached embedding %s: %v", entry.ContentHash, err)
+				continue
+			}
+			cached[entry.ContentHash] = vector
+		}
+	}
+
+	hits := 0
+	for i, hash := range hashes {
+		if vector, ok := cached[hash]; ok {
+			result[i] = vector
+			hits++
+		} else if first[hash] == i {
+			missing = append(missing, i)
+		}
+	}
+	// Copies of a text that is not cached are embedded along with it, they
+	// were not served from the cache
+	e.hits.Add(int64(hits))
+	e.misses.Add(int64(len(texts) - hits))
+	return hashes, missing, dups
+}
+
+// store saves newly requested embeddings; failures are logged only
+func (e *embeddingCache) store(modelName string, hashes []string, indexes []int, result [][]float32) {
+	entries := make([]*model.EmbeddingCache, len(indexes))
+	for i, index := range indexes {
+		entries[i] = &model.EmbeddingCache{
+			Model:       modelName,
+			ContentHash: hashes[index],
+			Embedding:   formatVector(result[index]),
+		}
+	}
+	if err := e.repo.BatchCreate(entries); err != nil {
+		log.Printf("Warning: failed to store embeddings in cache: %v", err)
+	}
+}
+
+// contentHash returns the hex SHA-256 of a text
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatVector converts a vector to pgvector text format without losing precision
+func formatVector(vector []float32) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// parseVector parses pgvector text format, e.g. [0.1,0.2]
+func parseVector(s string) ([]float32, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("invalid vector: %.20q", s)
+	}
+	s = s[1 : len(s)-1]
+	if s == "" {
+		return []float32{}, nil
+	}
+
+	parts := strings.Split(s, ",")
+	vector := make([]float32, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector element %d: %w", i, err)
+		}
+		vector[i] = float32(v)
+	}
+	return vector, nil
+}
@@ -3,16 +3,57 @@ package embedding
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cloudwego/eino-ext/components/embedding/openai"
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/zibianqu/eino_study/internal/config"
+	"golang.org/x/sync/errgroup"
+)
+
+// Embedding providers
+const (
+	ProviderOpenAI           = "openai"
+	ProviderAzure            = "azure"
+	ProviderOllama           = "ollama"
+	ProviderOpenAICompatible = "openai_compatible"
+	ProviderHash             = "hash"
+)
+
+// DefaultOllamaBaseURL is the OpenAI-compatible endpoint of a local Ollama server
+const DefaultOllamaBaseURL = "http://localhost:11434/v1"
+
+// Request defaults
+const (
+	DefaultBatchSize     = 64
+	DefaultConcurrency   = 4
+	DefaultMaxRetries    = 3
+	DefaultRetryInterval = 500 * time.Millisecond
 )
 
 // EmbeddingClient wraps Eino embedding component
 type EmbeddingClient struct {
-	embedder embedding.Embedder
+	backend atomic.Pointer[backend]
+	cache   *embeddingCache
+
+	// promoting is locked while the client switches model, and read locked
+	// by callers storing vectors embedded with the current one
+	promoting sync.RWMutex
+
+	batchSize     int
+	concurrency   int
+	maxRetries    int
+	retryInterval time.Duration
+}
+
+// backend is the model embeddings are requested from. It is swapped as a
+// whole when a re-embedded corpus is promoted to a new model.
+type backend struct {
+	embedder  embedding.Embedder
 	dimension int
+	model     string // provider/model/dimension, stored with every vector
 }
 
 // NewEmbeddingClient creates a new embedding client
@@ -21,26 +62,102 @@ func NewEmbeddingClient(cfg *config.EmbeddingConfig) (*EmbeddingClient, error) {
 		return nil, fmt.Errorf("embedding config is nil")
 	}
 
+	dimension, shorten, err := resolveDimension(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var dimensions *int
+	if shorten {
+		dimensions = &dimension
+	}
+
 	var embedder embedding.Embedder
-	var err error
 
 	switch cfg.Provider {
-	case "openai":
-		embedder, err = openai.NewEmbedder(context.Background(), &openai.EmbedderConfig{
-			APIKey: cfg.APIKey,
-			Model:  cfg.Model,
+	case ProviderOpenAI:
+		embedder, err = openai.NewEmbedder(context.Background(), &openai.EmbeddingConfig{
+			APIKey:     cfg.APIKey,
+			BaseURL:    cfg.BaseURL,
+			Model:      cfg.Model,
+			Dimensions: dimensions,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create OpenAI embedder: %w", err)
 		}
+	case ProviderAzure:
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("azure embedding provider requires base_url")
+		}
+		// Model is the name of the Azure deployment
+		embedder, err = openai.NewEmbedder(context.Background(), &openai.EmbeddingConfig{
+			ByAzure:    true,
+			APIKey:     cfg.APIKey,
+			BaseURL:    cfg.BaseURL,
+			APIVersion: cfg.APIVersion,
+			Model:      cfg.Model,
+			Dimensions: dimensions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure OpenAI embedder: %w", err)
+		}
+	case ProviderOllama, ProviderOpenAICompatible:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			if cfg.Provider != ProviderOllama {
+				return nil, fmt.Errorf("%s embedding provider requires base_url", cfg.Provider)
+			}
+			baseURL = DefaultOllamaBaseURL
+		}
+		// Local servers usually ignore the key, but the client always sends one
+		apiKey := cfg.APIKey
+		if apiKey == "" {
+			apiKey = cfg.Provider
+		}
+		embedder, err = openai.NewEmbedder(context.Background(), &openai.EmbeddingConfig{
+			APIKey:     apiKey,
+			BaseURL:    baseURL,
+			Model:      cfg.Model,
+			Dimensions: dimensions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s embedder: %w", cfg.Provider, err)
+		}
+	case ProviderHash:
+		embedder, err = NewHashEmbedder(dimension)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create hash embedder: %w", err)
+		}
 	default:
 		return nil, fmt.Errorf("unsupported embedding provider: %s", cfg.Provider)
 	}
 
-	return &EmbeddingClient{
+	client := &EmbeddingClient{
+		batchSize:     cfg.BatchSize,
+		concurrency:   cfg.Concurrency,
+		maxRetries:    cfg.MaxRetries,
+		retryInterval: cfg.RetryInterval,
+	}
+	client.backend.Store(&backend{
 		embedder:  embedder,
-		dimension: cfg.Dimension,
-	}, nil
+		dimension: dimension,
+		model:     fmt.Sprintf("%s/%s/%d", cfg.Provider, cfg.Model, dimension),
+	})
+	if client.batchSize <= 0 {
+		client.batchSize = DefaultBatchSize
+	}
+	if client.concurrency <= 0 {
+		client.concurrency = DefaultConcurrency
+	}
+	if client.maxRetries == 0 {
+		client.maxRetries = DefaultMaxRetries
+	} else if client.maxRetries < 0 {
+		client.maxRetries = 0
+	}
+	if client.retryInterval <= 0 {
+		client.retryInterval = DefaultRetryInterval
+	}
+
+	return client, nil
 }
 
 // EmbedText generates embedding for a single text
@@ -49,7 +166,7 @@ func (c *EmbeddingClient) EmbedText(ctx context.Context, text string) ([]float32
 		return nil, fmt.Errorf("text is empty")
 	}
 
-	vectors, err := c.embedder.EmbedStrings(ctx, []string{text})
+	vectors, err := c.EmbedTexts(ctx, []string{text})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
@@ -63,19 +180,136 @@ func (c *EmbeddingClient) EmbedText(ctx context.Context, text string) ([]float32
 
 // EmbedTexts generates embeddings for multiple texts
 func (c *EmbeddingClient) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	return c.EmbedTextsWithProgress(ctx, texts, nil)
+}
+
+// EmbedTextsWithProgress generates embeddings for multiple texts in batches,
+// sending up to concurrency batches at a time. With a cache, only texts not
+// embedded before are sent, and each batch is cached as soon as it returns,
+// so a failed run keeps its progress. progress, if set, is called after each
+// batch completes. The first batch to fail for good cancels the rest.
+func (c *EmbeddingClient) EmbedTextsWithProgress(ctx context.Context, texts []string, progress ProgressFunc) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("texts is empty")
 	}
 
-	vectors, err := c.embedder.EmbedStrings(ctx, texts)
-	if err != nil {
+	// A promotion to a new model applies from the next call
+	b := c.backend.Load()
+
+	result := make([][]float32, len(texts))
+	var (
+		hashes  []string
+		pending []int
+		dups    map[int][]int
+	)
+	if c.cache != nil {
+		hashes, pending, dups = c.cache.lookup(b.model, texts, result)
+	} else {
+		pending = make([]int, len(texts))
+		for i := range pending {
+			pending[i] = i
+		}
+	}
+
+	var mu sync.Mutex
+	done := len(texts) - len(pending)
+	for _, index := range pending {
+		done -= len(dups[index])
+	}
+	if done > 0 && progress != nil {
+		progress(done, len(texts))
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+	for start := 0; start < len(pending); start += c.batchSize {
+		batch := pending[start:min(start+c.batchSize, len(pending))]
+		g.Go(func() error {
+			batchTexts := make([]string, len(batch))
+			for i, index := range batch {
+				batchTexts[i] = texts[index]
+			}
+
+			vectors, err := c.embedWithRetry(gctx, b.embedder, batchTexts)
+			if err != nil {
+				return fmt.Errorf("failed to embed %d texts from text %d: %w", len(batch), batch[0], err)
+			}
+			if len(vectors) != len(batch) {
+				return fmt.Errorf("got %d embeddings for %d texts", len(vectors), len(batch))
+			}
+			for i, index := range batch {
+				if len(vectors[i]) != b.dimension {
+					return fmt.Errorf("embedding model %s returned %d dimensions, want %d", b.model, len(vectors[i]), b.dimension)
+				}
+				result[index] = toFloat32(vectors[i])
+			}
+			if c.cache != nil {
+				c.cache.store(b.model, hashes, batch, result)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, index := range batch {
+				done += 1 + len(dups[index])
+			}
+			if progress != nil {
+				progress(done, len(texts))
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
 		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
 	}
 
-	return vectors, nil
+	// Repeated texts were embedded once
+	for index, copies := range dups {
+		for _, i := range copies {
+			result[i] = result[index]
+		}
+	}
+
+	return result, nil
 }
 
 // GetDimension returns the embedding dimension
 func (c *EmbeddingClient) GetDimension() int {
-	return c.dimension
-}
\ No newline at end of file
+	return c.backend.Load().dimension
+}
+
+// Model identifies the model that produces the client's vectors as
+// provider/model/dimension. It is stored with every chunk vector.
+func (c *EmbeddingClient) Model() string {
+	return c.backend.Load().model
+}
+
+// Hold keeps the client on its current model until release is called, so
+// that vectors embedded meanwhile can be stored as made by Model. It blocks
+// while the client is being promoted.
+func (c *EmbeddingClient) Hold() (release func()) {
+	c.promoting.RLock()
+	return c.promoting.RUnlock
+}
+
+// Promote runs commit, which moves the stored corpus over to the model of
+// next, and if it succeeds switches the client to that model for all later
+// calls. Holders are waited for and new ones blocked until it returns.
+func (c *EmbeddingClient) Promote(next *EmbeddingClient, commit func() error) error {
+	c.promoting.Lock()
+	defer c.promoting.Unlock()
+
+	if err := commit(); err != nil {
+		return err
+	}
+	c.backend.Store(next.backend.Load())
+	return nil
+}
+
+// toFloat32 converts an Eino embedding to the float32 vectors stored in pgvector
+func toFloat32(vector []float64) []float32 {
+	result := make([]float32, len(vector))
+	for i, v := range vector {
+		result[i] = float32(v)
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zibianqu/eino_study/internal/config"
+)
+
+// modelDimensions lists the output dimension of well-known embedding models
+var modelDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+	"nomic-embed-text":       768,
+	"mxbai-embed-large":      1024,
+	"snowflake-arctic-embed": 1024,
+	"bge-m3":                 1024,
+	"bge-large":              1024,
+	"all-minilm":             384,
+}
+
+// shortenableModels return vectors of any smaller dimension when asked to
+var shortenableModels = map[string]bool{
+	"text-embedding-3-small": true,
+	"text-embedding-3-large": true,
+}
+
+// resolveDimension checks the configured dimension against the model and
+// returns it, or the model's own dimension when none is configured. shorten
+// is set when the provider has to be asked for shorter vectors. Unknown
+// models, such as Azure deployment names, need an explicit dimension.
+func resolveDimension(cfg *config.EmbeddingConfig) (dimension int, shorten bool, err error) {
+	if cfg.Dimension < 0 {
+		return 0, false, fmt.Errorf("embedding dimension must be positive, got %d", cfg.Dimension)
+	}
+	if cfg.Provider == ProviderHash {
+		if cfg.Dimension == 0 {
+			return 0, false, fmt.Errorf("hash embedding provider requires a dimension")
+		}
+		return cfg.Dimension, false, nil
+	}
+
+	// Ollama names carry a tag, e.g. nomic-embed-text:latest
+	name, _, _ := strings.Cut(strings.ToLower(cfg.Model), ":")
+	native, known := modelDimensions[name]
+	switch {
+	case !known && cfg.Dimension == 0:
+		return 0, false, fmt.Errorf("dimension of embedding model %q is unknown, set it in the config", cfg.Model)
+	case !known:
+		return cfg.Dimension, false, nil
+	case cfg.Dimension == 0 || cfg.Dimension == native:
+		return native, false, nil
+	case shortenableModels[name] && cfg.Dimension < native:
+		return cfg.Dimension, true, nil
+	case shortenableModels[name]:
+		return 0, false, fmt.Errorf("embedding model %s returns at most %d dimensions, configured %d", cfg.Model, native, cfg.Dimension)
+	default:
+		return 0, false, fmt.Errorf("embedding model %s returns %d dimensions, configured %d", cfg.Model, native, cfg.Dimension)
+	}
+}
@@ -0,0 +1,113 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// HashEmbedder is a deterministic offline embedder for development and CI.
+// It hashes words, CJK characters and CJK bigrams into a fixed number of
+// buckets, so texts sharing terms get similar vectors without any model or
+// network access. Its vectors carry no semantics beyond term overlap.
+type HashEmbedder struct {
+	dimension int
+}
+
+var _ embedding.Embedder = (*HashEmbedder)(nil)
+
+// NewHashEmbedder creates a hash embedder producing vectors of the given dimension
+func NewHashEmbedder(dimension int) (*HashEmbedder, error) {
+	if dimension <= 0 {
+		return nil, fmt.Errorf("dimension must be positive, got %d", dimension)
+	}
+	return &HashEmbedder{dimension: dimension}, nil
+}
+
+// EmbedStrings embeds each text as an L2-normalised bag of hashed terms
+func (e *HashEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+func (e *HashEmbedder) embed(text string) []float64 {
+	vector := make([]float64, e.dimension)
+	for _, term := range hashTerms(text) {
+		h := fnv.New64a()
+		h.Write([]byte(term))
+		sum := h.Sum64()
+
+		// The top bit picks the sign, so that collisions tend to cancel out
+		bucket := int(sum % uint64(e.dimension))
+		if sum>>63 == 1 {
+			vector[bucket]--
+		} else {
+			vector[bucket]++
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return vector
+}
+
+// hashTerms splits text into lower-cased words. CJK text has no spaces, so
+// each Han, kana or hangul character is a term, as is each pair of them.
+func hashTerms(text string) []string {
+	var terms []string
+	var word strings.Builder
+	var prev rune
+	flush := func() {
+		if word.Len() > 0 {
+			terms = append(terms, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flush()
+			terms = append(terms, string(r))
+			if prev != 0 {
+				terms = append(terms, string([]rune{prev, r}))
+			}
+			prev = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+		prev = 0
+	}
+	flush()
+
+	return terms
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
@@ -0,0 +1,82 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	goopenai "github.com/meguminnnnnnnnn/go-openai"
+)
+
+// maxRetryInterval caps the exponential backoff between retries
+const maxRetryInterval = 30 * time.Second
+
+// ProgressFunc reports how many of total texts have been embedded so far
+type ProgressFunc func(done, total int)
+
+// embedWithRetry embeds one batch, retrying transient failures with
+// exponential backoff and jitter
+func (c *EmbeddingClient) embedWithRetry(ctx context.Context, embedder embedding.Embedder, texts []string) ([][]float64, error) {
+	for attempt := 0; ; attempt++ {
+		vectors, err := embedder.EmbedStrings(ctx, texts)
+		if err == nil {
+			return vectors, nil
+		}
+		if attempt >= c.maxRetries || !isRetryable(ctx, err) {
+			return nil, err
+		}
+
+		timer := time.NewTimer(backoff(c.retryInterval, attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait before retry number attempt+1: the interval
+// doubled per attempt, of which the upper half is random so that parallel
+// batches do not retry in lockstep
+func backoff(interval time.Duration, attempt int) time.Duration {
+	d := interval
+	for i := 0; i < attempt && d < maxRetryInterval; i++ {
+		d *= 2
+	}
+	d = min(d, maxRetryInterval)
+	return d/2 + rand.N(d/2+1)
+}
+
+// isRetryable reports whether a failed request may succeed if sent again:
+// rate limits, server errors, timeouts and dropped connections
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *goopenai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode > 0 {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *goopenai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode > 0 {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests ||
+		code == http.StatusRequestTimeout ||
+		code >= http.StatusInternalServerError
+}
@@ -2,7 +2,9 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 
 	"github.com/cloudwego/eino/schema"
 	"github.com/zibianqu/eino_study/internal/app/repository"
@@ -14,24 +16,29 @@ import (
 
 // DocumentProcessor processes documents for RAG
 type DocumentProcessor struct {
-	loaderFactory *loader.LoaderFactory
-	splitter      *splitter.TextSplitter
-	embedding     *embedding.EmbeddingClient
-	chunkRepo     repository.ChunkRepository
+	loaderFactory  *loader.LoaderFactory
+	splitter       splitter.Splitter
+	parentSplitter splitter.Splitter
+	embedding      *embedding.EmbeddingClient
+	chunkRepo      repository.ChunkRepository
 }
 
-// NewDocumentProcessor creates a new document processor
+// NewDocumentProcessor creates a new document processor. When parentSplitter
+// is set, documents are first cut into large parent chunks, which splitter
+// then cuts into the small child chunks that get embedded.
 func NewDocumentProcessor(
 	loaderFactory *loader.LoaderFactory,
-	splitter *splitter.TextSplitter,
+	splitter splitter.Splitter,
+	parentSplitter splitter.Splitter,
 	embedding *embedding.EmbeddingClient,
 	chunkRepo repository.ChunkRepository,
 ) *DocumentProcessor {
 	return &DocumentProcessor{
-		loaderFactory: loaderFactory,
-		splitter:      splitter,
-		embedding:     embedding,
-		chunkRepo:     chunkRepo,
+		loaderFactory:  loaderFactory,
+		splitter:       splitter,
+		parentSplitter: parentSplitter,
+		embedding:      embedding,
+		chunkRepo:      chunkRepo,
 	}
 }
 
@@ -52,7 +59,20 @@ func (p *DocumentProcessor) Process(ctx context.Context, docID, filePath string)
 		return fmt.Errorf("no documents loaded")
 	}
 
-	// Step 2: Split document into chunks
+	// Step 2: Split document into chunks, or into parents and their children
+	var parents []*schema.Document
+	var children [][]*schema.Document
+	if p.parentSplitter != nil {
+		for _, doc := range docs {
+			chunks, err := p.parentSplitter.Transform(ctx, doc)
+			if err != nil {
+				return fmt.Errorf("failed to split document into parent chunks: %w", err)
+			}
+			parents = append(parents, chunks...)
+		}
+		docs = parents
+	}
+
 	var allChunks []*schema.Document
 	for _, doc := range docs {
 		chunks, err := p.splitter.Transform(ctx, doc)
@@ -60,6 +80,7 @@ func (p *DocumentProcessor) Process(ctx context.Context, docID, filePath string)
 			return fmt.Errorf("failed to split document: %w", err)
 		}
 		allChunks = append(allChunks, chunks...)
+		children = append(children, chunks)
 	}
 
 	if len(allChunks) == 0 {
@@ -72,35 +93,91 @@ func (p *DocumentProcessor) Process(ctx context.Context, docID, filePath string)
 		texts[i] = chunk.Content
 	}
 
-	vectors, err := p.embedding.EmbedTexts(ctx, texts)
+	// The model cannot be promoted by a re-embedding job until the vectors
+	// are stored, or they would be stored as vectors of the old model
+	release := p.embedding.Hold()
+	defer release()
+
+	// Large documents take many requests, so report how far embedding has got
+	embeddingModel := p.embedding.Model()
+	vectors, err := p.embedding.EmbedTextsWithProgress(ctx, texts, func(done, total int) {
+		log.Printf("Document %s: embedded %d/%d chunks", docID, done, total)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to generate embeddings: %w", err)
 	}
 
 	// Step 4: Store chunks with embeddings
-	dbChunks := make([]*model.DocumentChunk, len(allChunks))
-	for i, chunk := range allChunks {
-		chunkIndex := 0
-		if idx, ok := chunk.MetaData["chunk_index"].(int); ok {
-			chunkIndex = idx
+	// Loaders such as PDF return several documents per file, and the splitter
+	// numbers chunks per document, so chunk indexes are assigned file-wide here
+	if p.parentSplitter == nil {
+		dbChunks := make([]*model.DocumentChunk, len(allChunks))
+		for i, chunk := range allChunks {
+			dbChunks[i], err = newDBChunk(docID, i, model.ChunkTypeChunk, chunk, vectors[i], embeddingModel)
+			if err != nil {
+				return err
+			}
 		}
 
-		dbChunks[i] = &model.DocumentChunk{
-			DocID:      docID,
-			ChunkIndex: chunkIndex,
-			Content:    chunk.Content,
-			Embedding:  vectorToString(vectors[i]),
-			Metadata:   "", // Could store chunk.MetaData as JSON if needed
+		if err := p.chunkRepo.BatchCreate(dbChunks); err != nil {
+			return fmt.Errorf("failed to store chunks: %w", err)
 		}
+		return nil
 	}
 
-	if err := p.chunkRepo.BatchCreate(dbChunks); err != nil {
+	// Parents and children share one index sequence in reading order: each
+	// parent is followed by its children
+	dbParents := make([]*model.DocumentChunk, len(parents))
+	dbChildren := make([][]*model.DocumentChunk, len(parents))
+	index, vector := 0, 0
+	for i, parent := range parents {
+		dbParents[i], err = newDBChunk(docID, index, model.ChunkTypeParent, parent, nil, "")
+		if err != nil {
+			return err
+		}
+		index++
+
+		for _, child := range children[i] {
+			dbChild, err := newDBChunk(docID, index, model.ChunkTypeChild, child, vectors[vector], embeddingModel)
+			if err != nil {
+				return err
+			}
+			dbChildren[i] = append(dbChildren[i], dbChild)
+			index++
+			vector++
+		}
+	}
+
+	if err := p.chunkRepo.BatchCreateWithChildren(dbParents, dbChildren); err != nil {
 		return fmt.Errorf("failed to store chunks: %w", err)
 	}
 
 	return nil
 }
 
+// newDBChunk converts a split document into a chunk row; parents have no vector
+func newDBChunk(docID string, index int, chunkType string, chunk *schema.Document, vector []float32, embeddingModel string) (*model.DocumentChunk, error) {
+	chunk.MetaData["chunk_index"] = index
+	metadata, err := json.Marshal(chunk.MetaData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chunk metadata: %w", err)
+	}
+
+	dbChunk := &model.DocumentChunk{
+		DocID:      docID,
+		ChunkIndex: index,
+		ChunkType:  chunkType,
+		Content:    chunk.Content,
+		Metadata:   string(metadata),
+	}
+	if vector != nil {
+		embedding := vectorToString(vector)
+		dbChunk.Embedding = &embedding
+		dbChunk.EmbeddingModel = embeddingModel
+	}
+	return dbChunk, nil
+}
+
 // vectorToString converts vector to pgvector string format
 func vectorToString(vector []float32) string {
 	if len(vector) == 0 {
@@ -3,35 +3,57 @@ package graph
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/zibianqu/eino_study/internal/app/repository"
 	"github.com/zibianqu/eino_study/internal/eino/chatmodel"
+	"github.com/zibianqu/eino_study/internal/eino/prompt"
 	"github.com/zibianqu/eino_study/internal/eino/retriever"
 )
 
 // RAGChain orchestrates the RAG workflow
 type RAGChain struct {
-	retriever *retriever.VectorRetriever
+	retriever retriever.Retriever
 	chatModel *chatmodel.ChatModelClient
+	prompts   *prompt.Store
 }
 
 // NewRAGChain creates a new RAG chain
 func NewRAGChain(
-	retriever *retriever.VectorRetriever,
+	retriever retriever.Retriever,
 	chatModel *chatmodel.ChatModelClient,
+	prompts *prompt.Store,
 ) *RAGChain {
 	return &RAGChain{
 		retriever: retriever,
 		chatModel: chatModel,
+		prompts:   prompts,
 	}
 }
 
+// RAGRequest represents a question to the RAG chain
+type RAGRequest struct {
+	Query         string
+	PromptID      string // Prompt template, the configured default if empty
+	PromptVersion int    // Version of the prompt, the latest if 0
+
+	// Retrieval parameters, the configured ones if unset
+	TopK                int
+	SimilarityThreshold *float64
+	Filter              *repository.ChunkFilter
+}
+
 // RAGResponse represents the response from RAG chain
 type RAGResponse struct {
-	Answer  string
-	Sources []*schema.Document
-	Usage   *UsageInfo
+	Answer        string
+	Sources       []*schema.Document
+	Usage         *UsageInfo
+	Backend       string // LLM backend that generated the answer
+	Model         string // Model of that backend
+	PromptID      string
+	PromptVersion int
 }
 
 // UsageInfo represents token usage information
@@ -42,89 +64,156 @@ type UsageInfo struct {
 }
 
 // Run executes the RAG workflow
-func (c *RAGChain) Run(ctx context.Context, query string) (*RAGResponse, error) {
-	if query == "" {
-		return nil, fmt.Errorf("query is empty")
-	}
-
-	// Step 1: Retrieve relevant documents
-	docs, err := c.retriever.Retrieve(ctx, query)
+func (c *RAGChain) Run(ctx context.Context, req *RAGRequest) (*RAGResponse, error) {
+	tpl, docs, messages, err := c.prepare(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("retrieval failed: %w", err)
+		return nil, err
 	}
 
 	if len(docs) == 0 {
 		return &RAGResponse{
-			Answer:  "抱歉，我没有找到相关的文档来回答您的问题。",
-			Sources: []*schema.Document{},
+			Answer:        tpl.NoAnswer,
+			Sources:       []*schema.Document{},
+			PromptID:      tpl.ID,
+			PromptVersion: tpl.Version,
 		}, nil
 	}
 
-	// Step 2: Build context from retrieved documents
-	context := c.buildContext(docs)
+	// Step 3: Generate answer using LLM
+	response, err := c.chatModel.Generate(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("LLM generation failed: %w", err)
+	}
 
-	// Step 3: Build prompt
-	prompt := c.buildPrompt(query, context)
+	return &RAGResponse{
+		Answer:        response.Content,
+		Sources:       docs,
+		Usage:         usageFromMessage(response),
+		Backend:       chatmodel.Backend(response),
+		Model:         chatmodel.ModelName(response),
+		PromptID:      tpl.ID,
+		PromptVersion: tpl.Version,
+	}, nil
+}
 
-	// Step 4: Generate answer using LLM
-	messages := []*schema.Message{
-		{
-			Role:    schema.System,
-			Content: "你是一个专业的知识库助手。请根据提供的上下文信息回答用户的问题。如果上下文中没有相关信息，请明确说明。",
-		},
-		{
-			Role:    schema.User,
-			Content: prompt,
+// RunStream executes the RAG workflow with a streamed answer. The LLM stream
+// is already open when it returns, so failures to reach any backend are
+// reported here rather than by the stream.
+func (c *RAGChain) RunStream(ctx context.Context, req *RAGRequest) (*RAGStream, error) {
+	tpl, docs, messages, err := c.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &RAGStream{
+		Sources: docs,
+		response: RAGResponse{
+			Sources:       docs,
+			PromptID:      tpl.ID,
+			PromptVersion: tpl.Version,
 		},
 	}
+	if len(docs) == 0 {
+		stream.Sources = []*schema.Document{}
+		stream.pending = tpl.NoAnswer
+		return stream, nil
+	}
 
-	response, err := c.chatModel.Generate(ctx, messages)
+	// Step 3: Generate answer using LLM
+	stream.stream, err = c.chatModel.GenerateStream(ctx, messages)
 	if err != nil {
 		return nil, fmt.Errorf("LLM generation failed: %w", err)
 	}
+	return stream, nil
+}
 
-	// Extract usage info if available
-	usage := &UsageInfo{}
-	if response.ResponseMeta != nil {
-		if promptTokens, ok := response.ResponseMeta["prompt_tokens"].(int); ok {
-			usage.PromptTokens = promptTokens
-		}
-		if completionTokens, ok := response.ResponseMeta["completion_tokens"].(int); ok {
-			usage.CompletionTokens = completionTokens
-		}
-		if totalTokens, ok := response.ResponseMeta["total_tokens"].(int); ok {
-			usage.TotalTokens = totalTokens
-		}
+// prepare resolves the prompt, retrieves the documents and renders the
+// messages for the LLM; messages is nil when no document was found
+func (c *RAGChain) prepare(ctx context.Context, req *RAGRequest) (*prompt.Template, []*schema.Document, []*schema.Message, error) {
+	if req.Query == "" {
+		return nil, nil, nil, fmt.Errorf("query is empty")
 	}
 
-	return &RAGResponse{
-		Answer:  response.Content,
-		Sources: docs,
-		Usage:   usage,
-	}, nil
-}
+	// Resolve the prompt first so that an unknown prompt_id fails fast
+	tpl, err := c.prompts.Get(req.PromptID, req.PromptVersion)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-// buildContext builds context string from documents
-func (c *RAGChain) buildContext(docs []*schema.Document) string {
-	var builder strings.Builder
+	// Step 1: Retrieve relevant documents
+	opts := []retriever.Option{retriever.WithTopK(req.TopK), retriever.WithFilter(req.Filter)}
+	if req.SimilarityThreshold != nil {
+		opts = append(opts, retriever.WithThreshold(*req.SimilarityThreshold))
+	}
+	docs, err := c.retriever.Retrieve(ctx, req.Query, opts...)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("retrieval failed: %w", err)
+	}
+	if len(docs) == 0 {
+		return tpl, docs, nil, nil
+	}
 
-	for i, doc := range docs {
-		builder.WriteString(fmt.Sprintf("[文档 %d]\n", i+1))
-		builder.WriteString(doc.Content)
-		builder.WriteString("\n\n")
+	// Step 2: Render the prompt with the retrieved documents
+	messages, err := tpl.Render(ctx, req.Query, docs)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	return tpl, docs, messages, nil
+}
 
-	return builder.String()
+// RAGStream is an answer streamed from the RAG chain. The sources are known
+// up front, the answer arrives in deltas.
+type RAGStream struct {
+	Sources []*schema.Document
+
+	stream   *schema.StreamReader[*schema.Message]
+	pending  string // Reply without LLM call, returned as the only delta
+	answer   strings.Builder
+	usage    UsageCollector
+	response RAGResponse
 }
 
-// buildPrompt builds the final prompt with context and query
-func (c *RAGChain) buildPrompt(query, context string) string {
-	return fmt.Sprintf(`基于以下上下文信息回答问题。请确保答案准确、完整，并尽可能引用上下文中的具体内容。
+// Recv returns the next delta of the answer, and io.EOF after the last one
+func (s *RAGStream) Recv() (string, error) {
+	if s.stream == nil {
+		if s.pending == "" {
+			return "", io.EOF
+		}
+		delta := s.pending
+		s.pending = ""
+		s.answer.WriteString(delta)
+		return delta, nil
+	}
 
-上下文信息：
-%s
+	chunk, err := s.stream.Recv()
+	if err != nil {
+		return "", err
+	}
+	if chunk == nil {
+		return "", nil
+	}
+	if backend := chatmodel.Backend(chunk); backend != "" {
+		s.response.Backend = backend
+		s.response.Model = chatmodel.ModelName(chunk)
+	}
+	s.usage.Add(chunk)
+	s.answer.WriteString(chunk.Content)
+	return chunk.Content, nil
+}
 
-问题：%s
+// Close stops the LLM stream
+func (s *RAGStream) Close() {
+	if s.stream != nil {
+		s.stream.Close()
+	}
+}
 
-回答：`, context, query)
-}
\ No newline at end of file
+// Response returns the answer received so far with its usage
+func (s *RAGStream) Response() *RAGResponse {
+	response := s.response
+	response.Answer = s.answer.String()
+	if s.stream != nil {
+		response.Usage = s.usage.Usage()
+	}
+	return &response
+}
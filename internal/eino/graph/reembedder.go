@@ -0,0 +1,203 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/zibianqu/eino_study/internal/app/repository"
+	"github.com/zibianqu/eino_study/internal/eino/embedding"
+)
+
+// Re-embedding job states
+const (
+	ReembedIdle    = "idle"
+	ReembedRunning = "running"
+	ReembedDone    = "done"
+	ReembedFailed  = "failed"
+)
+
+// reembedBatchSize is how many chunks are loaded and stored at a time
+const reembedBatchSize = 256
+
+// ErrReembedRunning is returned when a re-embedding job is already running
+var ErrReembedRunning = errors.New("re-embedding is already running")
+
+// ReembedStatus reports the progress of a re-embedding job
+type ReembedStatus struct {
+	State      string     `json:"state"`
+	From       string     `json:"from"`
+	To         string     `json:"to"`
+	Total      int64      `json:"total"`
+	Done       int64      `json:"done"`
+	Promoted   bool       `json:"promoted"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Reembedder migrates stored chunk vectors to another embedding model in the
+// background. With a target model, new vectors go to a shadow column while
+// queries keep being served from the old ones; once every chunk is done, the
+// shadow column is promoted and the live client switches to the target model.
+// Without a target, or once promoted, chunks embedded by any other model are
+// re-embedded in place with the current one.
+type Reembedder struct {
+	chunkRepo repository.ChunkRepository
+	current   *embedding.EmbeddingClient
+	target    *embedding.EmbeddingClient
+
+	mu     sync.Mutex
+	status ReembedStatus
+}
+
+// NewReembedder creates a re-embedding job; target may be nil
+func NewReembedder(
+	chunkRepo repository.ChunkRepository,
+	current *embedding.EmbeddingClient,
+	target *embedding.EmbeddingClient,
+) *Reembedder {
+	return &Reembedder{
+		chunkRepo: chunkRepo,
+		current:   current,
+		target:    target,
+		status:    ReembedStatus{State: ReembedIdle},
+	}
+}
+
+// Start starts the job in the background and returns its initial status
+func (r *Reembedder) Start() (*ReembedStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.State == ReembedRunning {
+		return nil, ErrReembedRunning
+	}
+
+	now := time.Now()
+	r.status = ReembedStatus{
+		State:     ReembedRunning,
+		From:      r.current.Model(),
+		To:        r.targetClient().Model(),
+		StartedAt: &now,
+	}
+	status := r.status
+
+	go r.run(context.Background())
+	return &status, nil
+}
+
+// Status returns the status of the current or last job
+func (r *Reembedder) Status() *ReembedStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.status
+	return &status
+}
+
+// targetClient returns the client whose model the corpus is migrated to
+func (r *Reembedder) targetClient() *embedding.EmbeddingClient {
+	if r.target != nil {
+		return r.target
+	}
+	return r.current
+}
+
+func (r *Reembedder) run(ctx context.Context) {
+	err := r.migrate(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.status.FinishedAt = &now
+	if err != nil {
+		r.status.State = ReembedFailed
+		r.status.Error = err.Error()
+		log.Printf("Re-embedding to %s failed: %v", r.status.To, err)
+		return
+	}
+	r.status.State = ReembedDone
+	log.Printf("Re-embedding to %s done: %d chunks", r.status.To, r.status.Done)
+}
+
+func (r *Reembedder) migrate(ctx context.Context) error {
+	target := r.targetClient()
+	if target.Model() == r.current.Model() {
+		return r.reembed(ctx, target, false)
+	}
+
+	if err := r.reembed(ctx, target, true); err != nil {
+		return err
+	}
+
+	// Documents processed during the pass were embedded with the current
+	// model only. Processing is held while they are caught up and the shadow
+	// vectors promoted, so that none is stored with the old model afterwards.
+	err := r.current.Promote(target, func() error {
+		if err := r.reembed(ctx, target, true); err != nil {
+			return err
+		}
+		if err := r.chunkRepo.PromoteNextEmbeddings(target.Model(), target.GetDimension()); err != nil {
+			return fmt.Errorf("failed to promote re-embedded vectors: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.status.Promoted = true
+	r.mu.Unlock()
+	log.Printf("Promoted embedding model %s", target.Model())
+	return nil
+}
+
+// reembed embeds every stale chunk with client, into the shadow column if next is set
+func (r *Reembedder) reembed(ctx context.Context, client *embedding.EmbeddingClient, next bool) error {
+	embeddingModel := client.Model()
+	total, err := r.chunkRepo.CountStaleEmbeddings(embeddingModel, next)
+	if err != nil {
+		return fmt.Errorf("failed to count chunks to re-embed: %w", err)
+	}
+	r.mu.Lock()
+	r.status.Total += total
+	r.mu.Unlock()
+
+	afterID := 0
+	for {
+		chunks, err := r.chunkRepo.ListStaleEmbeddings(embeddingModel, next, afterID, reembedBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load chunks to re-embed: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		texts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = chunk.Content
+		}
+		vectors, err := client.EmbedTexts(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to re-embed chunks: %w", err)
+		}
+		for i, chunk := range chunks {
+			vector := vectorToString(vectors[i])
+			chunk.Embedding = &vector
+			chunk.EmbeddingModel = embeddingModel
+		}
+		if err := r.chunkRepo.UpdateEmbeddings(chunks, next); err != nil {
+			return fmt.Errorf("failed to store re-embedded chunks: %w", err)
+		}
+
+		afterID = chunks[len(chunks)-1].ID
+		r.mu.Lock()
+		r.status.Done += int64(len(chunks))
+		// Chunks of documents processed meanwhile are picked up as well
+		r.status.Total = max(r.status.Total, r.status.Done)
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"github.com/cloudwego/eino/schema"
+)
+
+// usageFromMessage reads the token usage the model reported with msg
+func usageFromMessage(msg *schema.Message) *UsageInfo {
+	usage := &UsageInfo{}
+	if msg != nil && msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+		usage.PromptTokens = msg.ResponseMeta.Usage.PromptTokens
+		usage.CompletionTokens = msg.ResponseMeta.Usage.CompletionTokens
+		usage.TotalTokens = msg.ResponseMeta.Usage.TotalTokens
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	return usage
+}
+
+// UsageCollector accumulates the token usage of a streamed response. Some
+// providers report running totals on every chunk and others only on the
+// last one, so the largest count seen wins.
+type UsageCollector struct {
+	usage UsageInfo
+}
+
+// Add records the usage reported with one chunk of the stream
+func (u *UsageCollector) Add(chunk *schema.Message) {
+	usage := usageFromMessage(chunk)
+	u.usage.PromptTokens = max(u.usage.PromptTokens, usage.PromptTokens)
+	u.usage.CompletionTokens = max(u.usage.CompletionTokens, usage.CompletionTokens)
+	u.usage.TotalTokens = max(u.usage.TotalTokens, usage.TotalTokens)
+}
+
+// Usage returns the usage of the chunks added so far
+func (u *UsageCollector) Usage() *UsageInfo {
+	usage := u.usage
+	return &usage
+}
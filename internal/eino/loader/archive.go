@@ -0,0 +1,306 @@
+package loader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// ArchiveOptions bounds how much an archive may expand to
+type ArchiveOptions struct {
+	MaxEntries   int     `mapstructure:"max_entries"`    // maximum number of files in an archive
+	MaxEntrySize int64   `mapstructure:"max_entry_size"` // maximum uncompressed size of a single file
+	MaxTotalSize int64   `mapstructure:"max_total_size"` // maximum uncompressed size of all files together
+	MaxRatio     float64 `mapstructure:"max_ratio"`      // maximum compression ratio of a zip entry
+	MaxDepth     int     `mapstructure:"max_depth"`      // maximum nesting of archives inside archives
+}
+
+// ArchiveLoader loads zip, tar and tar.gz archives by dispatching each entry
+// to the loader the factory picks for it
+type ArchiveLoader struct {
+	BaseLoader
+	options ArchiveOptions
+	factory *LoaderFactory
+}
+
+// factoryAware is implemented by loaders that dispatch back into the factory
+type factoryAware interface {
+	setFactory(f *LoaderFactory)
+}
+
+// archiveEntry is a regular file read from an archive
+type archiveEntry struct {
+	name string
+	data []byte
+}
+
+// archiveDepthKey carries the archive nesting depth through the context
+type archiveDepthKey struct{}
+
+// archiveBudgetKey carries the budget an archive shares with the archives
+// nested in it, so that the limits apply to the whole upload
+type archiveBudgetKey struct{}
+
+// NewArchiveLoader creates an archive loader with default limits
+func NewArchiveLoader() *ArchiveLoader {
+	loader, _ := NewArchiveLoaderWithOptions(ArchiveOptions{})
+	return loader
+}
+
+// NewArchiveLoaderWithOptions creates an archive loader with the given limits
+func NewArchiveLoaderWithOptions(options ArchiveOptions) (*ArchiveLoader, error) {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = 1000
+	}
+	if options.MaxEntrySize <= 0 {
+		options.MaxEntrySize = maxZipPartSize
+	}
+	if options.MaxTotalSize <= 0 {
+		options.MaxTotalSize = 256 << 20
+	}
+	if options.MaxRatio <= 0 {
+		options.MaxRatio = 100
+	}
+	if options.MaxDepth <= 0 {
+		options.MaxDepth = 2
+	}
+	if options.MaxEntrySize > options.MaxTotalSize {
+		return nil, fmt.Errorf("max_entry_size %d exceeds max_total_size %d", options.MaxEntrySize, options.MaxTotalSize)
+	}
+	return &ArchiveLoader{options: options}, nil
+}
+
+func (l *ArchiveLoader) setFactory(f *LoaderFactory) {
+	l.factory = f
+}
+
+// Load reads an archive and returns the documents of every supported entry
+func (l *ArchiveLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
+	if l.factory == nil {
+		return nil, fmt.Errorf("archive loader is not attached to a loader factory")
+	}
+
+	depth, _ := ctx.Value(archiveDepthKey{}).(int)
+	if depth >= l.options.MaxDepth {
+		return nil, fmt.Errorf("archive %s is nested deeper than %d levels", filePath, l.options.MaxDepth)
+	}
+	ctx = context.WithValue(ctx, archiveDepthKey{}, depth+1)
+
+	budget, ok := ctx.Value(archiveBudgetKey{}).(*archiveBudget)
+	if !ok {
+		budget = l.newBudget()
+		ctx = context.WithValue(ctx, archiveBudgetKey{}, budget)
+	}
+
+	content, err := l.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []archiveEntry
+	switch mime := mimetype.Detect(content); {
+	case mime.Is("application/zip"):
+		entries, err = l.readZip(content, budget)
+	case mime.Is("application/gzip"):
+		var gz *gzip.Reader
+		gz, err = gzip.NewReader(bytes.NewReader(content))
+		if err == nil {
+			entries, err = l.readTar(gz, budget)
+			gz.Close()
+		}
+	case mime.Is("application/x-tar"):
+		entries, err = l.readTar(bytes.NewReader(content), budget)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedContent, mime.String())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive %s: %w", filePath, err)
+	}
+
+	var docs []*schema.Document
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entryDocs, err := l.loadEntry(ctx, entry)
+		if errors.Is(err, ErrUnsupportedContent) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s in %s: %w", entry.name, filePath, err)
+		}
+
+		for _, doc := range entryDocs {
+			entryPath := entry.name
+			if inner, ok := doc.MetaData["entry_path"].(string); ok {
+				entryPath = entry.name + "/" + inner
+			}
+			doc.MetaData["source"] = filePath
+			doc.MetaData["file_name"] = path.Base(entryPath)
+			doc.MetaData["file_type"] = path.Ext(entryPath)
+			doc.MetaData["archive_path"] = filePath
+			doc.MetaData["entry_path"] = entryPath
+		}
+		docs = append(docs, entryDocs...)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("archive contains no supported files: %s", filePath)
+	}
+
+	return docs, nil
+}
+
+// loadEntry loads an entry from memory with the loader the factory picks for
+// it; nothing is written to disk
+func (l *ArchiveLoader) loadEntry(ctx context.Context, entry archiveEntry) ([]*schema.Document, error) {
+	if len(entry.data) == 0 {
+		return nil, ErrUnsupportedContent
+	}
+
+	loader, err := l.factory.getLoaderForContent(entry.name, entry.data)
+	if err != nil {
+		return nil, err
+	}
+	return loader.Load(ctx, entry.name)
+}
+
+// readZip reads the regular files of a zip archive within the configured limits
+func (l *ArchiveLoader) readZip(content []byte, budget *archiveBudget) ([]archiveEntry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	var entries []archiveEntry
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		name, ok := archiveEntryName(f.Name)
+		if !ok {
+			return nil, fmt.Errorf("unsafe entry path %q", f.Name)
+		}
+		if skipArchiveEntry(name) {
+			continue
+		}
+		if f.CompressedSize64 > 0 && float64(f.UncompressedSize64)/float64(f.CompressedSize64) > l.options.MaxRatio {
+			return nil, fmt.Errorf("entry %s exceeds compression ratio %.0f", name, l.options.MaxRatio)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open entry %s: %w", name, err)
+		}
+		data, err := budget.read(name, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{name: name, data: data})
+	}
+	return entries, nil
+}
+
+// readTar reads the regular files of a tar stream within the configured limits
+func (l *ArchiveLoader) readTar(r io.Reader, budget *archiveBudget) ([]archiveEntry, error) {
+	tr := tar.NewReader(r)
+	var entries []archiveEntry
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid tar archive: %w", err)
+		}
+		// Links, devices and directories are never followed or created
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, ok := archiveEntryName(header.Name)
+		if !ok {
+			return nil, fmt.Errorf("unsafe entry path %q", header.Name)
+		}
+		if skipArchiveEntry(name) {
+			continue
+		}
+
+		data, err := budget.read(name, tr)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{name: name, data: data})
+	}
+	return entries, nil
+}
+
+// archiveBudget tracks the entry count and bytes inflated so far, across an
+// archive and the archives nested in it
+type archiveBudget struct {
+	options   ArchiveOptions
+	entries   int
+	remaining int64
+}
+
+func (l *ArchiveLoader) newBudget() *archiveBudget {
+	return &archiveBudget{options: l.options, remaining: l.options.MaxTotalSize}
+}
+
+// read inflates one entry, counting actual bytes rather than trusting headers
+func (b *archiveBudget) read(name string, r io.Reader) ([]byte, error) {
+	b.entries++
+	if b.entries > b.options.MaxEntries {
+		return nil, fmt.Errorf("archive has more than %d files, nested archives included", b.options.MaxEntries)
+	}
+
+	limit := min(b.options.MaxEntrySize, b.remaining)
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entry %s: %w", name, err)
+	}
+	if int64(len(data)) > limit {
+		if limit < b.options.MaxEntrySize {
+			return nil, fmt.Errorf("archive expands beyond %d bytes", b.options.MaxTotalSize)
+		}
+		return nil, fmt.Errorf("entry %s exceeds %d bytes", name, b.options.MaxEntrySize)
+	}
+	b.remaining -= int64(len(data))
+	return data, nil
+}
+
+// archiveEntryName normalises an entry name, rejecting absolute paths and
+// names that would escape the archive root
+func archiveEntryName(name string) (string, bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':') {
+		return "", false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	name = path.Clean(name)
+	if name == "." || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// skipArchiveEntry reports entries that are metadata of the tool that built the archive
+func skipArchiveEntry(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(name, "__MACOSX/") || base == ".DS_Store" || base == "Thumbs.db" || strings.HasPrefix(base, "._")
+}
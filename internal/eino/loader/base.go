@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/cloudwego/eino/schema"
 )
@@ -15,10 +17,27 @@ type DocumentLoader interface {
 }
 
 // BaseLoader provides common functionality for all loaders
-type BaseLoader struct{}
+type BaseLoader struct {
+	content []byte // Content of a file held in memory, read instead of the path
+}
+
+// contentSetter is implemented by loaders that can read a file held in memory
+type contentSetter interface {
+	setContent(content []byte)
+}
+
+// setContent makes the loader read content instead of the file at the path
+// it is given, e.g. for archive entries
+func (l *BaseLoader) setContent(content []byte) {
+	l.content = content
+}
 
 // ReadFile reads file content from given path
 func (l *BaseLoader) ReadFile(filePath string) ([]byte, error) {
+	if l.content != nil {
+		return l.content, nil
+	}
+
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("file not found: %s", filePath)
 	}
@@ -31,6 +50,21 @@ func (l *BaseLoader) ReadFile(filePath string) ([]byte, error) {
 	return content, nil
 }
 
+// ReadText reads a text file and transcodes it to UTF-8, returning the detected charset
+func (l *BaseLoader) ReadText(filePath string) (string, string, error) {
+	content, err := l.ReadFile(filePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	text, charset, err := decodeText(content)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode %s: %w", filePath, err)
+	}
+
+	return text, charset, nil
+}
+
 // CreateDocument creates a schema.Document with metadata
 func (l *BaseLoader) CreateDocument(content, filePath string) *schema.Document {
 	return &schema.Document{
@@ -41,4 +75,46 @@ func (l *BaseLoader) CreateDocument(content, filePath string) *schema.Document {
 			"file_type": filepath.Ext(filePath),
 		},
 	}
-}
\ No newline at end of file
+}
+
+// setCharset records a non-UTF-8 source charset in document metadata
+func setCharset(metadata map[string]any, charset string) {
+	if charset != "" && charset != "utf-8" {
+		metadata["charset"] = charset
+	}
+}
+
+// setPosition records where a document's content starts within the file text,
+// for documents that hold only part of it. Splitters add chunk positions to it.
+func setPosition(metadata map[string]any, text string, start int) {
+	metadata["start_offset"] = utf8.RuneCountInString(text[:start])
+	metadata["start_line"] = strings.Count(text[:start], "\n") + 1
+}
+
+// normalizeText collapses repeated spaces and blank lines, leaving ``` fenced blocks untouched
+func normalizeText(text string) string {
+	lines := strings.Split(text, "\n")
+	result := make([]string, 0, len(lines))
+	blank := false
+	fenced := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		} else if fenced {
+			result = append(result, strings.TrimRight(line, " \t\r"))
+			blank = false
+			continue
+		}
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			if !blank && len(result) > 0 {
+				result = append(result, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		result = append(result, line)
+	}
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
@@ -0,0 +1,147 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+)
+
+// ErrUnsupportedContent is returned for content that no loader can turn into text
+var ErrUnsupportedContent = errors.New("unsupported binary content")
+
+// sniffLen is how much of the input is inspected for encoding heuristics
+const sniffLen = 8192
+
+// legacyCharsets are tried, in order, for text that is not valid UTF-8
+var legacyCharsets = []struct {
+	name     string
+	encoding encoding.Encoding
+	needsCJK bool
+}{
+	{"gb18030", simplifiedchinese.GB18030, true},
+	{"big5", traditionalchinese.Big5, true},
+	{"windows-1252", charmap.Windows1252, false},
+}
+
+// decodeText detects the character encoding of content and returns it as
+// UTF-8 together with the detected charset name
+func decodeText(content []byte) (string, string, error) {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		content = content[3:]
+		if utf8.Valid(content) {
+			return string(content), "utf-8", nil
+		}
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return transcode(content, "utf-16le", textunicode.UTF16(textunicode.LittleEndian, textunicode.UseBOM))
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return transcode(content, "utf-16be", textunicode.UTF16(textunicode.BigEndian, textunicode.UseBOM))
+	}
+
+	if endian, ok := detectUTF16(content); ok {
+		if endian == textunicode.LittleEndian {
+			return transcode(content, "utf-16le", textunicode.UTF16(endian, textunicode.IgnoreBOM))
+		}
+		return transcode(content, "utf-16be", textunicode.UTF16(endian, textunicode.IgnoreBOM))
+	}
+
+	if isBinary(content) {
+		return "", "", ErrUnsupportedContent
+	}
+
+	if utf8.Valid(content) {
+		return string(content), "utf-8", nil
+	}
+
+	for _, cs := range legacyCharsets {
+		text, err := cs.encoding.NewDecoder().Bytes(content)
+		if err != nil || bytes.ContainsRune(text, utf8.RuneError) {
+			continue
+		}
+		if cs.needsCJK && !containsHan(text) {
+			continue
+		}
+		return string(text), cs.name, nil
+	}
+
+	return "", "", fmt.Errorf("%w: unknown character encoding", ErrUnsupportedContent)
+}
+
+func transcode(content []byte, name string, enc encoding.Encoding) (string, string, error) {
+	text, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode %s text: %w", name, err)
+	}
+	return string(text), name, nil
+}
+
+// detectUTF16 recognises BOM-less UTF-16 by the NUL bytes that ASCII
+// characters leave in every other position
+func detectUTF16(content []byte) (textunicode.Endianness, bool) {
+	head := content
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+	if len(head) < 4 {
+		return textunicode.BigEndian, false
+	}
+
+	var evenZeros, oddZeros int
+	for i, c := range head {
+		if c != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+
+	half := len(head) / 2
+	switch {
+	case oddZeros > half*4/10 && evenZeros < half/20:
+		return textunicode.LittleEndian, true
+	case evenZeros > half*4/10 && oddZeros < half/20:
+		return textunicode.BigEndian, true
+	}
+	return textunicode.BigEndian, false
+}
+
+// isBinary reports whether content contains control bytes that never occur in text
+func isBinary(content []byte) bool {
+	head := content
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+
+	control := 0
+	for _, c := range head {
+		switch {
+		case c == 0:
+			return true
+		case c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != 0x1B:
+			control++
+		}
+	}
+	return len(head) > 0 && control*100/len(head) > 1
+}
+
+func containsHan(text []byte) bool {
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+		text = text[size:]
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// DOCXLoader loads Word documents (.docx)
+type DOCXLoader struct {
+	BaseLoader
+}
+
+// NewDOCXLoader creates a new DOCX loader
+func NewDOCXLoader() *DOCXLoader {
+	return &DOCXLoader{}
+}
+
+// Load reads a DOCX file and returns a document with headings rendered as markdown
+func (l *DOCXLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
+	content, err := l.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := openZipPackage(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX %s: %w", filePath, err)
+	}
+
+	body, err := pkg.read("word/document.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX %s: %w", filePath, err)
+	}
+
+	text, err := extractDOCXText(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DOCX %s: %w", filePath, err)
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("file is empty: %s", filePath)
+	}
+
+	doc := l.CreateDocument(text, filePath)
+	doc.MetaData["format"] = "docx"
+	title, author := pkg.coreProperties()
+	setCoreMetadata(doc.MetaData, title, author)
+
+	return []*schema.Document{doc}, nil
+}
+
+// extractDOCXText walks word/document.xml, emitting one line per paragraph,
+// markdown headings for heading styles and pipe-separated table rows
+func extractDOCXText(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		lines     []string
+		paragraph strings.Builder
+		heading   int
+		row       []string
+		cell      []string
+		tableLvl  int
+	)
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				heading = 0
+			case "pStyle":
+				heading = docxHeadingLevel(attrValue(t.Attr, "val"))
+			case "outlineLvl":
+				// Outline level 9 is body text
+				if lvl, err := strconv.Atoi(attrValue(t.Attr, "val")); err == nil && lvl >= 0 && lvl < 9 && heading == 0 {
+					heading = min(lvl+1, maxHeadingLevel)
+				}
+			case "tab":
+				paragraph.WriteByte('\t')
+			case "br", "cr":
+				paragraph.WriteByte('\n')
+			case "t":
+				var text string
+				if err := decoder.DecodeElement(&text, &t); err != nil {
+					return "", err
+				}
+				paragraph.WriteString(text)
+			case "tbl":
+				tableLvl++
+			case "tr":
+				row = row[:0]
+			case "tc":
+				cell = cell[:0]
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				text := strings.TrimSpace(paragraph.String())
+				paragraph.Reset()
+				if text == "" {
+					continue
+				}
+				if tableLvl > 0 {
+					cell = append(cell, text)
+					continue
+				}
+				if heading > 0 {
+					text = strings.Repeat("#", heading) + " " + text
+				}
+				lines = append(lines, text)
+			case "tc":
+				row = append(row, strings.Join(cell, " "))
+			case "tr":
+				if len(row) > 0 {
+					lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+				}
+			case "tbl":
+				tableLvl--
+			}
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n\n")), nil
+}
+
+// maxHeadingLevel is the deepest markdown heading; Word has nine levels
+const maxHeadingLevel = 6
+
+// docxHeadingLevel maps a paragraph style ID such as Heading2 or Title to a
+// heading level, deeper headings than markdown has are rendered at level 6
+func docxHeadingLevel(style string) int {
+	lower := strings.ToLower(style)
+	switch {
+	case lower == "title":
+		return 1
+	case strings.HasPrefix(lower, "heading"):
+		if lvl, err := strconv.Atoi(strings.TrimSpace(lower[len("heading"):])); err == nil && lvl > 0 && lvl <= 9 {
+			return min(lvl, maxHeadingLevel)
+		}
+	}
+	return 0
+}
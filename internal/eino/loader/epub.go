@@ -0,0 +1,137 @@
+package loader
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// EPUBLoader loads EPUB e-books, producing one document per chapter
+type EPUBLoader struct {
+	BaseLoader
+}
+
+// NewEPUBLoader creates a new EPUB loader
+func NewEPUBLoader() *EPUBLoader {
+	return &EPUBLoader{}
+}
+
+// epubPackage is the subset of the OPF package document used by the loader
+type epubPackage struct {
+	Title    string `xml:"metadata>title"`
+	Creator  string `xml:"metadata>creator"`
+	Language string `xml:"metadata>language"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef  string `xml:"idref,attr"`
+		Linear string `xml:"linear,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// Load reads an EPUB file and returns the chapters listed in its spine
+func (l *EPUBLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
+	content, err := l.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := openZipPackage(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB %s: %w", filePath, err)
+	}
+
+	container, err := pkg.read("META-INF/container.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB %s: %w", filePath, err)
+	}
+
+	var rootfiles struct {
+		Items []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(container, &rootfiles); err != nil || len(rootfiles.Items) == 0 {
+		return nil, fmt.Errorf("EPUB %s has no package document", filePath)
+	}
+
+	opfPath := rootfiles.Items[0].FullPath
+	opfData, err := pkg.read(opfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EPUB package %s: %w", filePath, err)
+	}
+
+	var opf epubPackage
+	if err := xml.Unmarshal(opfData, &opf); err != nil {
+		return nil, fmt.Errorf("failed to parse EPUB package %s: %w", filePath, err)
+	}
+
+	hrefs := make(map[string]string, len(opf.Manifest))
+	for _, item := range opf.Manifest {
+		if strings.Contains(item.MediaType, "html") {
+			hrefs[item.ID] = path.Join(path.Dir(opfPath), item.Href)
+		}
+	}
+
+	bookTitle := strings.TrimSpace(opf.Title)
+	author := strings.TrimSpace(opf.Creator)
+
+	var docs []*schema.Document
+	for _, ref := range opf.Spine {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		href, ok := hrefs[ref.IDRef]
+		if !ok || ref.Linear == "no" {
+			continue
+		}
+		data, err := pkg.read(href)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chapter %s: %w", href, err)
+		}
+
+		chapter, err := parseHTMLPage(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chapter %s: %w", href, err)
+		}
+		if chapter.text == "" {
+			continue
+		}
+
+		doc := l.CreateDocument(chapter.text, filePath)
+		doc.MetaData["format"] = "epub"
+		doc.MetaData["chapter_index"] = len(docs)
+		doc.MetaData["chapter_path"] = href
+		if chapterTitle := firstNonEmpty(chapter.heading, chapter.title); chapterTitle != "" {
+			doc.MetaData["chapter_title"] = chapterTitle
+		}
+		if opf.Language != "" {
+			doc.MetaData["language"] = strings.TrimSpace(opf.Language)
+		}
+		setCoreMetadata(doc.MetaData, bookTitle, author)
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("file is empty: %s", filePath)
+	}
+
+	return docs, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
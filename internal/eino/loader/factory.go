@@ -2,31 +2,192 @@ package loader
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/zibianqu/eino_study/internal/config"
 )
 
+// loaderEntry is a matcher with the constructor and options used when it matches
+type loaderEntry struct {
+	matcher     LoaderMatcher
+	constructor LoaderConstructor
+	options     map[string]any
+}
+
 // LoaderFactory creates appropriate loader based on file type
-type LoaderFactory struct{}
+type LoaderFactory struct {
+	mu      sync.RWMutex
+	entries []loaderEntry
+}
+
+// NewLoaderFactory creates a loader factory with the built-in loaders, overridden
+// by the rules of the eino.loaders config section
+func NewLoaderFactory(rules []config.LoaderConfig) (*LoaderFactory, error) {
+	f := &LoaderFactory{}
+	f.registerBuiltins()
+
+	for i, rule := range rules {
+		if err := f.registerRule(rule); err != nil {
+			return nil, fmt.Errorf("invalid loader rule %d (%s): %w", i, rule.Loader, err)
+		}
+	}
+
+	return f, nil
+}
+
+// registerBuiltins registers the default loaders, lowest priority first
+func (f *LoaderFactory) registerBuiltins() {
+	f.RegisterLoader(func(info FileInfo) bool { return info.Text }, withoutOptions(NewTextLoader))
+	f.RegisterLoader(MatchText(".md", ".markdown"), withoutOptions(NewMarkdownLoader))
+	f.RegisterLoader(MatchAny(
+		MatchMIME("text/html", "application/xhtml+xml"),
+		MatchText(".html", ".htm", ".xhtml"),
+	), withoutOptions(NewHTMLLoader))
+	f.RegisterLoader(MatchAny(
+		MatchMIME("text/csv", "text/tab-separated-values"),
+		MatchText(".csv", ".tsv"),
+	), newStructuredLoaderFromOptions(""))
+	f.RegisterLoader(MatchAny(
+		MatchMIME("application/json", "application/x-ndjson"),
+		MatchText(".json", ".jsonl", ".ndjson"),
+	), newStructuredLoaderFromOptions(""))
+	// Zip based document formats below are registered later and win over the archive loader
+	f.RegisterLoader(MatchMIME("application/zip", "application/x-tar", "application/gzip"), newArchiveLoaderFromOptions)
+	f.RegisterLoader(MatchMIME("application/pdf"), withoutOptions(NewPDFLoader))
+	f.RegisterLoader(MatchMIME("application/vnd.openxmlformats-officedocument.wordprocessingml.document"), withoutOptions(NewDOCXLoader))
+	f.RegisterLoader(MatchMIME("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"), withoutOptions(NewXLSXLoader))
+	f.RegisterLoader(MatchMIME("application/vnd.openxmlformats-officedocument.presentationml.presentation"), withoutOptions(NewPPTXLoader))
+	f.RegisterLoader(MatchMIME("application/epub+zip"), withoutOptions(NewEPUBLoader))
+}
 
-// NewLoaderFactory creates a new loader factory
-func NewLoaderFactory() *LoaderFactory {
-	return &LoaderFactory{}
+// registerRule registers a loader from a config rule
+func (f *LoaderFactory) registerRule(rule config.LoaderConfig) error {
+	constructor, ok := lookupLoaderType(rule.Loader)
+	if !ok {
+		return fmt.Errorf("unknown loader %q, available: %s", rule.Loader, strings.Join(LoaderTypes(), ", "))
+	}
+	if len(rule.Extensions) == 0 && len(rule.MimeTypes) == 0 {
+		return fmt.Errorf("no extensions or mime_types given")
+	}
+
+	// Build once so that bad options fail at startup rather than on first upload
+	if _, err := constructor(rule.Options); err != nil {
+		return err
+	}
+
+	var matchers []LoaderMatcher
+	if len(rule.Extensions) > 0 {
+		matchers = append(matchers, MatchExtensions(rule.Extensions...))
+	}
+	if len(rule.MimeTypes) > 0 {
+		matchers = append(matchers, MatchMIME(rule.MimeTypes...))
+	}
+
+	f.mu.Lock()
+	f.entries = append(f.entries, loaderEntry{
+		matcher:     MatchAny(matchers...),
+		constructor: constructor,
+		options:     rule.Options,
+	})
+	f.mu.Unlock()
+	return nil
 }
 
-// GetLoader returns appropriate loader for the given file path
+// RegisterLoader adds a loader to the factory. Loaders registered later take
+// precedence over earlier ones, including the built-in loaders.
+func (f *LoaderFactory) RegisterLoader(matcher LoaderMatcher, constructor LoaderConstructor) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append(f.entries, loaderEntry{matcher: matcher, constructor: constructor})
+}
+
+// GetLoader returns appropriate loader for the given file path. Files are
+// matched on their sniffed content type, so a misnamed file still reaches the
+// right loader; the extension only refines the choice between text formats.
 func (f *LoaderFactory) GetLoader(filePath string) (DocumentLoader, error) {
-	ext := strings.ToLower(filepath.Ext(filePath))
-
-	switch ext {
-	case ".txt":
-		return NewTextLoader(), nil
-	case ".md", ".markdown":
-		return NewMarkdownLoader(), nil
-	case ".pdf":
-		return nil, fmt.Errorf("PDF loader not implemented yet")
-	default:
-		// Default to text loader for unknown types
-		return NewTextLoader(), nil
-	}
-}
\ No newline at end of file
+	mime, err := mimetype.DetectFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", filePath)
+		}
+		return nil, fmt.Errorf("failed to detect content type: %w", err)
+	}
+
+	return f.loaderFor(newFileInfo(filePath, mime, looksLikeText(filePath)))
+}
+
+// getLoaderForContent returns the loader for a file held in memory, set up
+// to read content instead of the file at name
+func (f *LoaderFactory) getLoaderForContent(name string, content []byte) (DocumentLoader, error) {
+	head := content[:min(len(content), sniffLen)]
+	loader, err := f.loaderFor(newFileInfo(name, mimetype.Detect(content), headLooksLikeText(head)))
+	if err != nil {
+		return nil, err
+	}
+
+	setter, ok := loader.(contentSetter)
+	if !ok {
+		return nil, fmt.Errorf("%w: loader of %s only reads files", ErrUnsupportedContent, filepath.Base(name))
+	}
+	setter.setContent(content)
+	return loader, nil
+}
+
+// newFileInfo describes a file to the loader matchers
+func newFileInfo(filePath string, mime *mimetype.MIME, looksLikeText bool) FileInfo {
+	info := FileInfo{
+		Path:      filePath,
+		Extension: strings.ToLower(filepath.Ext(filePath)),
+		MIME:      mime,
+	}
+	info.Text = info.IsMIME("text/plain") || looksLikeText
+	return info
+}
+
+// loaderFor creates the loader of the most recently registered matching entry
+func (f *LoaderFactory) loaderFor(info FileInfo) (DocumentLoader, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for i := len(f.entries) - 1; i >= 0; i-- {
+		entry := f.entries[i]
+		if entry.matcher(info) {
+			loader, err := entry.constructor(entry.options)
+			if err != nil {
+				return nil, err
+			}
+			if aware, ok := loader.(factoryAware); ok {
+				aware.setFactory(f)
+			}
+			return loader, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s (%s)", ErrUnsupportedContent, filepath.Base(info.Path), info.MIME.String())
+}
+
+// looksLikeText catches text the sniffer reports as binary, such as UTF-16 without a BOM
+func looksLikeText(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, _ := file.Read(head)
+	return headLooksLikeText(head[:n])
+}
+
+// headLooksLikeText is looksLikeText on the first bytes of a file
+func headLooksLikeText(head []byte) bool {
+	if len(head) == 0 {
+		// Empty files are left to the text loader, which reports them clearly
+		return true
+	}
+	_, ok := detectUTF16(head[:len(head)&^1])
+	return ok
+}
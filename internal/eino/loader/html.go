@@ -0,0 +1,459 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	htmlcharset "golang.org/x/net/html/charset"
+)
+
+// HTMLLoader loads HTML pages, dropping page chrome and keeping the readable content
+type HTMLLoader struct {
+	BaseLoader
+}
+
+// NewHTMLLoader creates a new HTML loader
+func NewHTMLLoader() *HTMLLoader {
+	return &HTMLLoader{}
+}
+
+// Load reads an HTML file and returns its main content as markdown-like text
+func (l *HTMLLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
+	content, err := l.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) == 0 {
+		return nil, fmt.Errorf("file is empty: %s", filePath)
+	}
+
+	text, charset, err := decodeHTML(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode HTML %s: %w", filePath, err)
+	}
+
+	page, err := parseHTMLPage([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML %s: %w", filePath, err)
+	}
+
+	if page.text == "" {
+		return nil, fmt.Errorf("no readable content in HTML: %s", filePath)
+	}
+
+	doc := l.CreateDocument(page.text, filePath)
+	doc.MetaData["format"] = "html"
+	setCharset(doc.MetaData, charset)
+	if page.title != "" {
+		doc.MetaData["title"] = page.title
+	}
+	if len(page.links) > 0 {
+		doc.MetaData["links"] = page.links
+	}
+
+	return []*schema.Document{doc}, nil
+}
+
+// decodeHTML transcodes an HTML document to UTF-8, honouring a BOM or a
+// <meta charset> declaration before falling back to content heuristics
+func decodeHTML(content []byte) (string, string, error) {
+	enc, name, _ := htmlcharset.DetermineEncoding(content, "text/html")
+	switch {
+	case name == "windows-1252":
+		// No declaration found, this is only the default guess
+	case name == "utf-8" && !utf8.Valid(content):
+		// Declared UTF-8 but the bytes say otherwise
+	default:
+		text, err := enc.NewDecoder().Bytes(content)
+		if err != nil {
+			return "", "", err
+		}
+		return strings.TrimPrefix(string(text), "\uFEFF"), name, nil
+	}
+	return decodeText(content)
+}
+
+// htmlPage is the result of rendering an HTML document
+type htmlPage struct {
+	title   string   // <title>, or the first heading when there is none
+	heading string   // first heading of the content
+	text    string   // rendered content
+	links   []string // outbound links found in the content, deduplicated
+}
+
+// parseHTMLPage parses and renders an HTML document
+func parseHTMLPage(content []byte) (*htmlPage, error) {
+	root, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &htmlPage{}
+	var base *url.URL
+	walkHTML(root, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Title:
+			if page.title == "" {
+				page.title = strings.TrimSpace(nodeText(n))
+			}
+		case atom.Base:
+			if href := htmlAttr(n, "href"); href != "" && base == nil {
+				base, _ = url.Parse(href)
+			}
+		}
+		return true
+	})
+
+	r := &htmlRenderer{base: base, seen: make(map[string]bool)}
+	r.render(contentRoot(root))
+	page.text = normalizeText(r.b.String())
+	page.heading = r.firstHeading
+	page.links = r.links
+	if page.title == "" {
+		page.title = page.heading
+	}
+	return page, nil
+}
+
+// htmlSkipped are elements that never carry readable content
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Head: true, atom.Iframe: true, atom.Svg: true, atom.Canvas: true,
+	atom.Form: true, atom.Button: true, atom.Select: true, atom.Object: true,
+}
+
+// htmlBoilerplate are page chrome elements removed from the content
+var htmlBoilerplate = map[atom.Atom]bool{
+	atom.Nav: true, atom.Aside: true,
+}
+
+// htmlPageChrome are elements that are chrome at page level, but part of the
+// content inside an article or main, e.g. the header holding an article's title
+var htmlPageChrome = map[atom.Atom]bool{
+	atom.Header: true, atom.Footer: true,
+}
+
+// boilerplateRoles and boilerplateTokens identify chrome by ARIA role or class/id
+var boilerplateRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true, "search": true,
+}
+
+// boilerplateTokens are matched against whole class names and ids only, and
+// kept to names hardly ever given to content
+var boilerplateTokens = map[string]bool{
+	"nav": true, "navbar": true, "navigation": true, "sidebar": true,
+	"breadcrumb": true, "breadcrumbs": true, "site-header": true, "site-footer": true,
+	"site-nav": true, "cookie-banner": true, "cookie-consent": true, "skip-link": true,
+}
+
+// isBoilerplate reports whether a node is navigation or other page chrome
+func isBoilerplate(n *html.Node) bool {
+	if htmlSkipped[n.DataAtom] || htmlBoilerplate[n.DataAtom] {
+		return true
+	}
+	if htmlPageChrome[n.DataAtom] && !inHTMLContent(n) {
+		return true
+	}
+	if boilerplateRoles[strings.ToLower(htmlAttr(n, "role"))] {
+		return true
+	}
+	if hasHTMLAttr(n, "hidden") || htmlAttr(n, "aria-hidden") == "true" {
+		return true
+	}
+	for _, attr := range []string{"class", "id"} {
+		for _, token := range strings.Fields(strings.ToLower(htmlAttr(n, attr))) {
+			if boilerplateTokens[token] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inHTMLContent reports whether a node lies inside an article or main
+func inHTMLContent(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.DataAtom == atom.Article || p.DataAtom == atom.Main || htmlAttr(p, "role") == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// contentRoot prefers <main>, role=main or a single <article> over the whole body
+func contentRoot(root *html.Node) *html.Node {
+	var main, body *html.Node
+	var articles []*html.Node
+	walkHTML(root, func(n *html.Node) bool {
+		switch {
+		case n.DataAtom == atom.Main || htmlAttr(n, "role") == "main":
+			if main == nil {
+				main = n
+			}
+		case n.DataAtom == atom.Article:
+			articles = append(articles, n)
+		case n.DataAtom == atom.Body:
+			body = n
+		}
+		return true
+	})
+
+	switch {
+	case main != nil:
+		return main
+	case len(articles) == 1:
+		return articles[0]
+	case body != nil:
+		return body
+	}
+	return root
+}
+
+// htmlRenderer renders a node tree as plain text with markdown headings,
+// list bullets and pipe tables
+type htmlRenderer struct {
+	b            strings.Builder
+	base         *url.URL
+	links        []string
+	seen         map[string]bool
+	firstHeading string
+	pre          int
+}
+
+func (r *htmlRenderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if r.pre > 0 {
+			r.b.WriteString(n.Data)
+		} else {
+			r.b.WriteString(collapseSpaces(n.Data))
+		}
+		return
+	case html.ElementNode:
+		if isBoilerplate(n) {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.Join(strings.Fields(nodeText(n)), " ")
+		if text == "" {
+			return
+		}
+		if r.firstHeading == "" {
+			r.firstHeading = text
+		}
+		level := int(n.Data[1] - '0')
+		r.b.WriteString("\n\n" + strings.Repeat("#", level) + " " + text + "\n\n")
+		r.collectLinks(n)
+		return
+	case atom.Table:
+		r.renderTable(n)
+		return
+	case atom.Br:
+		r.b.WriteString("\n")
+		return
+	case atom.Hr:
+		r.b.WriteString("\n\n")
+		return
+	case atom.Img:
+		if alt := strings.TrimSpace(htmlAttr(n, "alt")); alt != "" {
+			r.b.WriteString(" " + alt + " ")
+		}
+		return
+	case atom.A:
+		r.addLink(htmlAttr(n, "href"))
+	case atom.Li:
+		r.b.WriteString("\n- ")
+	case atom.Pre:
+		r.pre++
+		r.b.WriteString("\n\n```\n")
+		defer func() {
+			r.pre--
+			r.b.WriteString("\n```\n\n")
+		}()
+	}
+
+	block := isHTMLBlock(n.DataAtom)
+	if block {
+		r.b.WriteString("\n\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+	if block {
+		r.b.WriteString("\n\n")
+	}
+}
+
+// renderTable renders a table as pipe-separated rows, adding a separator under a header row
+func (r *htmlRenderer) renderTable(table *html.Node) {
+	var rows [][]string
+	headerRows := 0
+	walkHTML(table, func(n *html.Node) bool {
+		if n != table && n.DataAtom == atom.Table {
+			// Nested tables are flattened into the enclosing cell text
+			return false
+		}
+		if n.DataAtom != atom.Tr {
+			return true
+		}
+		var cells []string
+		allHeader := true
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom != atom.Td && c.DataAtom != atom.Th {
+				continue
+			}
+			if c.DataAtom == atom.Td {
+				allHeader = false
+			}
+			cells = append(cells, strings.Join(strings.Fields(nodeText(c)), " "))
+			r.collectLinks(c)
+		}
+		if len(cells) > 0 {
+			if allHeader && len(rows) == headerRows {
+				headerRows++
+			}
+			rows = append(rows, cells)
+		}
+		return false
+	})
+
+	if len(rows) == 0 {
+		return
+	}
+
+	r.b.WriteString("\n\n")
+	for i, row := range rows {
+		r.b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if headerRows > 0 && i == headerRows-1 {
+			r.b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	r.b.WriteString("\n")
+}
+
+// collectLinks records the links below n, for subtrees rendered via nodeText
+func (r *htmlRenderer) collectLinks(n *html.Node) {
+	walkHTML(n, func(c *html.Node) bool {
+		if c.DataAtom == atom.A {
+			r.addLink(htmlAttr(c, "href"))
+		}
+		return true
+	})
+}
+
+// addLink resolves and records an outbound link, ignoring fragments and script URLs
+func (r *htmlRenderer) addLink(href string) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "javascript", "data":
+		return
+	}
+	if r.base != nil {
+		u = r.base.ResolveReference(u)
+	}
+	u.Fragment = ""
+	link := u.String()
+	if link == "" || r.seen[link] {
+		return
+	}
+	r.seen[link] = true
+	r.links = append(r.links, link)
+}
+
+// isHTMLBlock reports whether an element starts a new paragraph
+func isHTMLBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Blockquote,
+		atom.Ul, atom.Ol, atom.Dl, atom.Dt, atom.Dd, atom.Figure, atom.Figcaption,
+		atom.Address, atom.Details, atom.Summary, atom.Body:
+		return true
+	}
+	return false
+}
+
+// walkHTML visits nodes depth-first; fn returns false to skip a node's children
+func walkHTML(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkHTML(c, fn)
+	}
+}
+
+// nodeText returns the concatenated text below n, skipping non-content elements
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	walkHTML(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && htmlSkipped[c.DataAtom] && c != n {
+			return false
+		}
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+		if c.DataAtom == atom.Br {
+			b.WriteString(" ")
+		}
+		return true
+	})
+	return b.String()
+}
+
+// htmlAttr returns the value of an attribute
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasHTMLAttr reports whether an attribute is present, including valueless ones
+func hasHTMLAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// collapseSpaces turns runs of whitespace into single spaces, as browsers do
+func collapseSpaces(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\n' || r == '\t' || r == '\r' || r == '\f' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -3,23 +3,76 @@ package loader
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/zibianqu/eino_study/internal/pkg/markdown"
+	"gopkg.in/yaml.v3"
 )
 
+// Front matter handling modes
+const (
+	FrontMatterParse = "parse" // parse into metadata and remove from content
+	FrontMatterStrip = "strip" // remove from content without parsing
+	FrontMatterKeep  = "keep"  // leave content untouched
+)
+
+// Markdown document modes
+const (
+	MarkdownModeDocument = "document" // one document per file
+	MarkdownModeSections = "sections" // one document per heading section
+)
+
+// MarkdownOptions configures the markdown loader
+type MarkdownOptions struct {
+	FrontMatter  string `mapstructure:"front_matter"`  // parse, strip or keep
+	Mode         string `mapstructure:"mode"`          // document or sections
+	SectionLevel int    `mapstructure:"section_level"` // deepest heading level that starts a section
+}
+
 // MarkdownLoader loads markdown files
 type MarkdownLoader struct {
 	BaseLoader
+	options MarkdownOptions
 }
 
 // NewMarkdownLoader creates a new markdown loader
 func NewMarkdownLoader() *MarkdownLoader {
-	return &MarkdownLoader{}
+	loader, _ := NewMarkdownLoaderWithOptions(MarkdownOptions{})
+	return loader
 }
 
-// Load reads a markdown file and returns a document
+// NewMarkdownLoaderWithOptions creates a markdown loader with the given options
+func NewMarkdownLoaderWithOptions(options MarkdownOptions) (*MarkdownLoader, error) {
+	if options.FrontMatter == "" {
+		options.FrontMatter = FrontMatterParse
+	}
+	if options.Mode == "" {
+		options.Mode = MarkdownModeDocument
+	}
+	if options.SectionLevel <= 0 || options.SectionLevel > 6 {
+		options.SectionLevel = 6
+	}
+
+	switch options.FrontMatter {
+	case FrontMatterParse, FrontMatterStrip, FrontMatterKeep:
+	default:
+		return nil, fmt.Errorf("unknown front_matter mode: %s", options.FrontMatter)
+	}
+	switch options.Mode {
+	case MarkdownModeDocument, MarkdownModeSections:
+	default:
+		return nil, fmt.Errorf("unknown markdown mode: %s", options.Mode)
+	}
+
+	return &MarkdownLoader{options: options}, nil
+}
+
+// Load reads a markdown file and returns one document, or one per section
 func (l *MarkdownLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
-	content, err := l.ReadFile(filePath)
+	content, charset, err := l.ReadText(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -28,8 +81,142 @@ func (l *MarkdownLoader) Load(ctx context.Context, filePath string) ([]*schema.D
 		return nil, fmt.Errorf("file is empty: %s", filePath)
 	}
 
-	doc := l.CreateDocument(string(content), filePath)
-	doc.MetaData["format"] = "markdown"
+	text := content
+	var frontMatter map[string]any
+	if l.options.FrontMatter != FrontMatterKeep {
+		var raw, format string
+		raw, format, content = splitFrontMatter(content)
+		if raw != "" && l.options.FrontMatter == FrontMatterParse {
+			frontMatter, err = parseFrontMatter(raw, format)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse front matter in %s: %w", filePath, err)
+			}
+		}
+	}
+
+	newDoc := func(text string) *schema.Document {
+		doc := l.CreateDocument(text, filePath)
+		for k, v := range frontMatter {
+			if _, reserved := doc.MetaData[k]; !reserved {
+				doc.MetaData[k] = v
+			}
+		}
+		doc.MetaData["format"] = "markdown"
+		setCharset(doc.MetaData, charset)
+		return doc
+	}
 
-	return []*schema.Document{doc}, nil
-}
\ No newline at end of file
+	if l.options.Mode == MarkdownModeDocument {
+		if strings.TrimSpace(content) == "" {
+			return nil, fmt.Errorf("file is empty: %s", filePath)
+		}
+		doc := newDoc(content)
+		if body := len(text) - len(content); body > 0 {
+			setPosition(doc.MetaData, text, body)
+		}
+		return []*schema.Document{doc}, nil
+	}
+
+	var docs []*schema.Document
+	cursor := len(text) - len(content)
+	for _, section := range markdown.Split(content, l.options.SectionLevel, nil) {
+		sectionText := strings.TrimSpace(section.Text)
+		doc := newDoc(sectionText)
+		if i := strings.Index(text[cursor:], sectionText); i >= 0 {
+			cursor += i
+			setPosition(doc.MetaData, text, cursor)
+		}
+		doc.MetaData["section_index"] = len(docs)
+		if len(section.Headings) > 0 {
+			doc.MetaData["section_title"] = section.Headings[len(section.Headings)-1]
+			doc.MetaData["section_level"] = section.Level
+			doc.MetaData["headings"] = section.Headings
+			doc.MetaData["heading_path"] = strings.Join(section.Headings, " > ")
+		}
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("file is empty: %s", filePath)
+	}
+
+	return docs, nil
+}
+
+// splitFrontMatter separates YAML (---) or TOML (+++) front matter from the body
+func splitFrontMatter(content string) (raw, format, body string) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	firstLine, rest, found := strings.Cut(content, "\n")
+	if !found {
+		return "", "", content
+	}
+
+	var closers []string
+	switch strings.TrimSpace(firstLine) {
+	case "---":
+		format, closers = "yaml", []string{"---", "..."}
+	case "+++":
+		format, closers = "toml", []string{"+++"}
+	default:
+		return "", "", content
+	}
+
+	offset := 0
+	for offset <= len(rest) {
+		line, _, _ := strings.Cut(rest[offset:], "\n")
+		for _, closer := range closers {
+			if strings.TrimRight(line, " \t\r") == closer {
+				body = rest[min(offset+len(line)+1, len(rest)):]
+				return rest[:offset], format, body
+			}
+		}
+		if offset+len(line) >= len(rest) {
+			break
+		}
+		offset += len(line) + 1
+	}
+
+	// Unterminated: treat the delimiter as content
+	return "", "", content
+}
+
+// parseFrontMatter decodes front matter into metadata values
+func parseFrontMatter(raw, format string) (map[string]any, error) {
+	values := make(map[string]any)
+	var err error
+	if format == "toml" {
+		err = toml.Unmarshal([]byte(raw), &values)
+	} else {
+		err = yaml.Unmarshal([]byte(raw), &values)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range values {
+		values[k] = normalizeFrontMatterValue(v)
+	}
+	return values, nil
+}
+
+// normalizeFrontMatterValue turns dates into strings so metadata stays JSON friendly
+func normalizeFrontMatterValue(v any) any {
+	switch t := v.(type) {
+	case time.Time:
+		if t.Equal(t.Truncate(24*time.Hour)) && t.Location() == time.UTC {
+			return t.Format(time.DateOnly)
+		}
+		return t.Format(time.RFC3339)
+	case toml.LocalDate, toml.LocalTime, toml.LocalDateTime:
+		return fmt.Sprint(t)
+	case []any:
+		for i := range t {
+			t[i] = normalizeFrontMatterValue(t[i])
+		}
+	case map[string]any:
+		for k := range t {
+			t[k] = normalizeFrontMatterValue(t[k])
+		}
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+package loader
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxZipPartSize bounds how much a single part of a zipped document may inflate to
+const maxZipPartSize = 64 << 20
+
+// zipPackage gives access to the parts of a zip based format (OOXML, EPUB)
+type zipPackage struct {
+	files map[string]*zip.File
+}
+
+// openZipPackage opens zip content held in memory
+func openZipPackage(content []byte) (*zipPackage, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip container: %w", err)
+	}
+
+	pkg := &zipPackage{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		pkg.files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+	return pkg, nil
+}
+
+// has reports whether the package contains the named part
+func (p *zipPackage) has(name string) bool {
+	_, ok := p.files[strings.TrimPrefix(name, "/")]
+	return ok
+}
+
+// read returns the content of the named part
+func (p *zipPackage) read(name string) ([]byte, error) {
+	f, ok := p.files[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return nil, fmt.Errorf("part not found: %s", name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open part %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read part %s: %w", name, err)
+	}
+	if len(data) > maxZipPartSize {
+		return nil, fmt.Errorf("part %s exceeds %d bytes", name, maxZipPartSize)
+	}
+	return data, nil
+}
+
+// ooxmlRelationship is an entry of a _rels/*.rels part
+type ooxmlRelationship struct {
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+	Target string `xml:"Target,attr"`
+}
+
+// relationships returns the relationships of a part keyed by ID, with targets
+// resolved to package paths
+func (p *zipPackage) relationships(partName string) map[string]ooxmlRelationship {
+	dir, file := path.Split(partName)
+	data, err := p.read(path.Join(dir, "_rels", file+".rels"))
+	if err != nil {
+		return nil
+	}
+
+	var rels struct {
+		Items []ooxmlRelationship `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal(data, &rels); err != nil {
+		return nil
+	}
+
+	result := make(map[string]ooxmlRelationship, len(rels.Items))
+	for _, rel := range rels.Items {
+		if strings.HasPrefix(rel.Target, "/") {
+			rel.Target = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			rel.Target = path.Join(dir, rel.Target)
+		}
+		result[rel.ID] = rel
+	}
+	return result
+}
+
+// coreProperties reads title and author from docProps/core.xml
+func (p *zipPackage) coreProperties() (title, author string) {
+	data, err := p.read("docProps/core.xml")
+	if err != nil {
+		return "", ""
+	}
+
+	var core struct {
+		Title   string `xml:"title"`
+		Creator string `xml:"creator"`
+	}
+	if err := xml.Unmarshal(data, &core); err != nil {
+		return "", ""
+	}
+	return strings.TrimSpace(core.Title), strings.TrimSpace(core.Creator)
+}
+
+// attrValue returns the value of the attribute with the given local name
+func attrValue(attrs []xml.Attr, local string) string {
+	for _, a := range attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// relationshipID returns the r:id attribute that points into a relationships part
+func relationshipID(attrs []xml.Attr) string {
+	for _, a := range attrs {
+		if a.Name.Local == "id" && strings.HasSuffix(a.Name.Space, "/relationships") {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// setCoreMetadata copies title and author into document metadata when present
+func setCoreMetadata(metadata map[string]any, title, author string) {
+	if title != "" {
+		metadata["title"] = title
+	}
+	if author != "" {
+		metadata["author"] = author
+	}
+}
@@ -0,0 +1,73 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// PDFLoader loads PDF files, producing one document per page
+type PDFLoader struct {
+	BaseLoader
+}
+
+// NewPDFLoader creates a new PDF loader
+func NewPDFLoader() *PDFLoader {
+	return &PDFLoader{}
+}
+
+// Load reads a PDF file and returns one document per page with extractable text
+func (l *PDFLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
+	content, err := l.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) == 0 {
+		return nil, fmt.Errorf("file is empty: %s", filePath)
+	}
+
+	pdf, err := parsePDF(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PDF %s: %w", filePath, err)
+	}
+
+	pages := pdf.pages()
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no pages found in PDF: %s", filePath)
+	}
+
+	title := pdf.infoString("Title")
+	author := pdf.infoString("Author")
+
+	docs := make([]*schema.Document, 0, len(pages))
+	for i, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		text := pdf.extractPageText(page)
+		if text == "" {
+			continue
+		}
+
+		doc := l.CreateDocument(text, filePath)
+		doc.MetaData["format"] = "pdf"
+		doc.MetaData["page_number"] = i + 1
+		doc.MetaData["total_pages"] = len(pages)
+		if title != "" {
+			doc.MetaData["title"] = title
+		}
+		if author != "" {
+			doc.MetaData["author"] = author
+		}
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no extractable text in PDF (scanned or image-only?): %s", filePath)
+	}
+
+	return docs, nil
+}
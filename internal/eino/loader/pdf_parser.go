@@ -0,0 +1,661 @@
+package loader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfName is a PDF name object such as /Type
+type pdfName string
+
+// pdfKeyword is a bare PDF token such as obj, R, [ or a content stream operator
+type pdfKeyword string
+
+// pdfString holds the raw bytes of a literal or hex string
+type pdfString string
+
+// pdfDict is a PDF dictionary
+type pdfDict map[pdfName]any
+
+// pdfRef is an indirect object reference
+type pdfRef struct {
+	num int
+	gen int
+}
+
+// pdfStream is a stream object with its still-encoded data
+type pdfStream struct {
+	dict pdfDict
+	data []byte
+}
+
+// pdfLexer tokenizes PDF file and content stream syntax
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// skipSpace skips whitespace and comments
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// next returns the next token, or io.EOF at the end of input
+func (l *pdfLexer) next() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.readName(), nil
+	case c == '(':
+		return l.readLiteralString(), nil
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), nil
+		}
+		return l.readHexString(), nil
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), nil
+		}
+		l.pos++
+		return pdfKeyword(">"), nil
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	case c == ')':
+		l.pos++
+		return pdfKeyword(")"), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	if n, err := strconv.ParseFloat(word, 64); err == nil && (word[0] == '-' || word[0] == '+' || word[0] == '.' || (word[0] >= '0' && word[0] <= '9')) {
+		return n, nil
+	}
+	return pdfKeyword(word), nil
+}
+
+func (l *pdfLexer) readName() pdfName {
+	l.pos++ // skip '/'
+	var b strings.Builder
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b.WriteByte(c)
+		l.pos++
+	}
+	return pdfName(b.String())
+}
+
+func (l *pdfLexer) readLiteralString() pdfString {
+	l.pos++ // skip '('
+	var b bytes.Buffer
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(b.String())
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				continue
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b.WriteByte(byte(v))
+				} else {
+					b.WriteByte(e)
+				}
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return pdfString(b.String())
+}
+
+func (l *pdfLexer) readHexString() pdfString {
+	l.pos++ // skip '<'
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		c := l.data[l.pos]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // skip '>'
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	hex.Decode(out, digits)
+	return pdfString(out)
+}
+
+// readObject reads a complete object, combining "num gen R" into a pdfRef
+func (l *pdfLexer) readObject() (any, error) {
+	tok, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	return l.completeObject(tok)
+}
+
+func (l *pdfLexer) completeObject(tok any) (any, error) {
+	switch t := tok.(type) {
+	case pdfKeyword:
+		switch t {
+		case "[":
+			var arr []any
+			for {
+				item, err := l.next()
+				if err != nil {
+					return arr, err
+				}
+				if item == pdfKeyword("]") {
+					return arr, nil
+				}
+				obj, err := l.completeObject(item)
+				if err != nil {
+					return arr, err
+				}
+				arr = append(arr, obj)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				key, err := l.next()
+				if err != nil {
+					return dict, err
+				}
+				if key == pdfKeyword(">>") {
+					return dict, nil
+				}
+				name, ok := key.(pdfName)
+				if !ok {
+					continue
+				}
+				value, err := l.readObject()
+				if err != nil {
+					return dict, err
+				}
+				dict[name] = value
+			}
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t, nil
+	case float64:
+		// Look ahead for an indirect reference "num gen R"
+		save := l.pos
+		gen, err := l.next()
+		if g, ok := gen.(float64); ok && err == nil {
+			if r, err := l.next(); err == nil && r == pdfKeyword("R") {
+				return pdfRef{num: int(t), gen: int(g)}, nil
+			}
+		}
+		l.pos = save
+		return t, nil
+	}
+	return tok, nil
+}
+
+// pdfDocument holds all indirect objects of a parsed PDF file
+type pdfDocument struct {
+	objects map[int]any
+	trailer pdfDict
+}
+
+var pdfObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// parsePDF scans the file for indirect objects instead of trusting the
+// cross-reference table, which keeps damaged or incrementally updated
+// files readable
+func parsePDF(data []byte) (*pdfDocument, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+
+	doc := &pdfDocument{objects: make(map[int]any), trailer: pdfDict{}}
+
+	lastEnd := 0
+	for _, m := range pdfObjHeader.FindAllSubmatchIndex(data, -1) {
+		if m[0] < lastEnd {
+			continue
+		}
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		lex := &pdfLexer{data: data, pos: m[1]}
+		obj, err := lex.readObject()
+		if err != nil {
+			continue
+		}
+		if dict, ok := obj.(pdfDict); ok {
+			save := lex.pos
+			if tok, err := lex.next(); err == nil && tok == pdfKeyword("stream") {
+				obj = readPDFStream(data, lex, dict)
+			} else {
+				lex.pos = save
+			}
+		}
+		doc.objects[num] = obj
+		lastEnd = lex.pos
+	}
+
+	doc.loadObjectStreams()
+	doc.loadTrailer(data)
+
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("encrypted PDF files are not supported")
+	}
+
+	return doc, nil
+}
+
+// readPDFStream reads stream data following the "stream" keyword
+func readPDFStream(data []byte, lex *pdfLexer, dict pdfDict) *pdfStream {
+	start := lex.pos
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+
+	end := -1
+	// A negative or overflowing Length is ignored like a wrong one
+	if length, ok := dict["Length"].(float64); ok && length >= 0 {
+		e := start + int(length)
+		if e >= start && e <= len(data) && bytes.HasPrefix(bytes.TrimLeft(data[e:], "\r\n \t"), []byte("endstream")) {
+			end = e
+		}
+	}
+	if end < 0 {
+		idx := bytes.Index(data[start:], []byte("endstream"))
+		if idx < 0 {
+			end = len(data)
+		} else {
+			end = start + idx
+			for end > start && (data[end-1] == '\n' || data[end-1] == '\r') {
+				end--
+			}
+		}
+	}
+
+	lex.pos = end
+	if idx := bytes.Index(data[end:], []byte("endstream")); idx >= 0 {
+		lex.pos = end + idx + len("endstream")
+	}
+	return &pdfStream{dict: dict, data: data[start:end]}
+}
+
+// loadObjectStreams unpacks objects stored inside /Type /ObjStm streams
+func (d *pdfDocument) loadObjectStreams() {
+	for _, obj := range d.objects {
+		stream, ok := obj.(*pdfStream)
+		if !ok || stream.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		content, err := d.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+		n := d.intValue(stream.dict["N"])
+		first := d.intValue(stream.dict["First"])
+		if first <= 0 || first > len(content) {
+			continue
+		}
+
+		header := &pdfLexer{data: content[:first]}
+		for i := 0; i < n; i++ {
+			numTok, err1 := header.next()
+			offTok, err2 := header.next()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			num, ok1 := numTok.(float64)
+			off, ok2 := offTok.(float64)
+			if !ok1 || !ok2 || first+int(off) >= len(content) {
+				continue
+			}
+			if _, exists := d.objects[int(num)]; exists {
+				continue
+			}
+			lex := &pdfLexer{data: content, pos: first + int(off)}
+			if value, err := lex.readObject(); err == nil {
+				d.objects[int(num)] = value
+			}
+		}
+	}
+}
+
+// loadTrailer merges classic trailers and cross-reference stream dictionaries
+func (d *pdfDocument) loadTrailer(data []byte) {
+	for _, obj := range d.objects {
+		if stream, ok := obj.(*pdfStream); ok && stream.dict["Type"] == pdfName("XRef") {
+			for k, v := range stream.dict {
+				d.trailer[k] = v
+			}
+		}
+	}
+
+	// Later trailers belong to later incremental updates and win
+	offset := 0
+	for {
+		idx := bytes.Index(data[offset:], []byte("trailer"))
+		if idx < 0 {
+			break
+		}
+		lex := &pdfLexer{data: data, pos: offset + idx + len("trailer")}
+		if obj, err := lex.readObject(); err == nil {
+			if dict, ok := obj.(pdfDict); ok {
+				for k, v := range dict {
+					d.trailer[k] = v
+				}
+			}
+		}
+		offset += idx + len("trailer")
+	}
+
+	if _, ok := d.trailer["Root"]; !ok {
+		for num, obj := range d.objects {
+			if dict, ok := obj.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+				d.trailer["Root"] = pdfRef{num: num}
+				break
+			}
+		}
+	}
+}
+
+// resolve follows indirect references
+func (d *pdfDocument) resolve(v any) any {
+	for i := 0; i < 32; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = d.objects[ref.num]
+	}
+	return nil
+}
+
+func (d *pdfDocument) dictValue(v any) pdfDict {
+	switch t := d.resolve(v).(type) {
+	case pdfDict:
+		return t
+	case *pdfStream:
+		return t.dict
+	}
+	return nil
+}
+
+func (d *pdfDocument) intValue(v any) int {
+	if f, ok := d.resolve(v).(float64); ok {
+		return int(f)
+	}
+	return 0
+}
+
+// decodeStream applies the stream's filters
+func (d *pdfDocument) decodeStream(s *pdfStream) ([]byte, error) {
+	var filters []any
+	switch f := d.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{f}
+	case []any:
+		filters = f
+	}
+
+	data := s.data
+	for _, f := range filters {
+		name, _ := d.resolve(f).(pdfName)
+		var err error
+		switch name {
+		case "FlateDecode", "Fl":
+			data, err = inflatePDF(data)
+		case "ASCIIHexDecode", "AHx":
+			lex := &pdfLexer{data: append([]byte{'<'}, data...)}
+			data = []byte(lex.readHexString())
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported PDF filter: %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// maxPDFStreamSize bounds how much a single stream may inflate to, against
+// compression bombs
+const maxPDFStreamSize = 64 << 20
+
+func inflatePDF(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate stream: %w", err)
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, maxPDFStreamSize+1))
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("failed to inflate stream: %w", err)
+	}
+	if len(out) > maxPDFStreamSize {
+		return nil, fmt.Errorf("stream exceeds %d bytes when inflated", maxPDFStreamSize)
+	}
+	// Truncated streams are common; keep whatever was decoded
+	return out, nil
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if idx := bytes.Index(data, []byte("~>")); idx >= 0 {
+		data = data[:idx]
+	}
+	out := make([]byte, len(data)*4/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ASCII85 stream: %w", err)
+	}
+	return out[:n], nil
+}
+
+// pdfPage is a leaf of the page tree with its inherited resources
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages of the document in reading order
+func (d *pdfDocument) pages() []pdfPage {
+	root := d.dictValue(d.trailer["Root"])
+	if root == nil {
+		return nil
+	}
+
+	var result []pdfPage
+	visited := make(map[int]bool)
+	var walk func(node any, resources pdfDict, depth int)
+	walk = func(node any, resources pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref.num] {
+				return
+			}
+			visited[ref.num] = true
+		}
+		dict := d.dictValue(node)
+		if dict == nil || depth > 64 {
+			return
+		}
+		if res := d.dictValue(dict["Resources"]); res != nil {
+			resources = res
+		}
+		kids, ok := d.resolve(dict["Kids"]).([]any)
+		if !ok || dict["Type"] == pdfName("Page") {
+			result = append(result, pdfPage{dict: dict, resources: resources})
+			return
+		}
+		for _, kid := range kids {
+			walk(kid, resources, depth+1)
+		}
+	}
+	walk(root["Pages"], nil, 0)
+
+	return result
+}
+
+// pageContent returns the decoded, concatenated content streams of a page
+func (d *pdfDocument) pageContent(page pdfPage) []byte {
+	var streams []any
+	switch c := d.resolve(page.dict["Contents"]).(type) {
+	case *pdfStream:
+		streams = []any{c}
+	case []any:
+		streams = c
+	}
+
+	var buf bytes.Buffer
+	for _, s := range streams {
+		stream, ok := d.resolve(s).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := d.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// infoString returns a decoded entry of the document information dictionary
+func (d *pdfDocument) infoString(key pdfName) string {
+	info := d.dictValue(d.trailer["Info"])
+	if info == nil {
+		return ""
+	}
+	s, ok := d.resolve(info[key]).(pdfString)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(decodePDFTextString(string(s)))
+}
+
+// decodePDFTextString decodes a PDF text string (UTF-16BE with BOM, UTF-8 with BOM or PDFDocEncoding)
+func decodePDFTextString(s string) string {
+	switch {
+	case strings.HasPrefix(s, "\xfe\xff"):
+		return decodeUTF16BE([]byte(s[2:]))
+	case strings.HasPrefix(s, "\xef\xbb\xbf"):
+		return s[3:]
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteRune(winAnsiRune(s[i]))
+	}
+	return b.String()
+}
+
+func decodeUTF16BE(b []byte) string {
+	return string(utf16.Decode(utf16Units(b)))
+}
+
+// winAnsiHigh maps the 0x80-0x9F range of WinAnsiEncoding, which differs from Latin-1
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘',
+	0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜',
+	0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+func winAnsiRune(c byte) rune {
+	if r, ok := winAnsiHigh[c]; ok {
+		return r
+	}
+	return rune(c)
+}
@@ -0,0 +1,422 @@
+package loader
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfFont maps character codes of a font to Unicode text
+type pdfFont struct {
+	composite  bool              // Type0 font with multi-byte codes
+	toUnicode  map[string]string // code bytes -> text, from the /ToUnicode CMap
+	codeLens   []int             // code lengths declared in the CMap codespace ranges
+	difference map[byte]rune     // /Encoding /Differences of simple fonts
+}
+
+// decode converts a string shown with this font to Unicode
+func (f *pdfFont) decode(s string) string {
+	var b strings.Builder
+	if f == nil {
+		for i := 0; i < len(s); i++ {
+			b.WriteRune(winAnsiRune(s[i]))
+		}
+		return b.String()
+	}
+
+	for i := 0; i < len(s); {
+		matched := false
+		if f.toUnicode != nil {
+			for _, n := range f.codeLens {
+				if i+n <= len(s) {
+					if text, ok := f.toUnicode[s[i:i+n]]; ok {
+						b.WriteString(text)
+						i += n
+						matched = true
+						break
+					}
+				}
+			}
+		}
+		if matched {
+			continue
+		}
+
+		if f.composite {
+			// Without a usable ToUnicode entry composite codes cannot be mapped
+			i += 2
+			continue
+		}
+		if r, ok := f.difference[s[i]]; ok {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(winAnsiRune(s[i]))
+		}
+		i++
+	}
+	return b.String()
+}
+
+// loadFont builds a pdfFont from a font dictionary
+func (d *pdfDocument) loadFont(dict pdfDict) *pdfFont {
+	font := &pdfFont{composite: dict["Subtype"] == pdfName("Type0")}
+
+	if stream, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := d.decodeStream(stream); err == nil {
+			font.toUnicode, font.codeLens = parseToUnicodeCMap(data)
+		}
+	}
+	if len(font.codeLens) == 0 {
+		if font.composite {
+			font.codeLens = []int{2}
+		} else {
+			font.codeLens = []int{1}
+		}
+	}
+
+	if enc := d.dictValue(dict["Encoding"]); enc != nil {
+		if diffs, ok := d.resolve(enc["Differences"]).([]any); ok {
+			font.difference = make(map[byte]rune)
+			code := 0
+			for _, item := range diffs {
+				switch v := d.resolve(item).(type) {
+				case float64:
+					code = int(v)
+				case pdfName:
+					if r, ok := glyphNameRune(string(v)); ok && code < 256 {
+						font.difference[byte(code)] = r
+					}
+					code++
+				}
+			}
+		}
+	}
+
+	return font
+}
+
+// parseToUnicodeCMap reads bfchar and bfrange mappings from a ToUnicode CMap
+func parseToUnicodeCMap(data []byte) (map[string]string, []int) {
+	mapping := make(map[string]string)
+	lenSet := make(map[int]bool)
+	lex := &pdfLexer{data: data}
+
+	readHex := func() (string, bool) {
+		obj, err := lex.readObject()
+		if err != nil {
+			return "", false
+		}
+		s, ok := obj.(pdfString)
+		return string(s), ok
+	}
+
+	for {
+		tok, err := lex.next()
+		if err == io.EOF {
+			break
+		}
+		switch tok {
+		case pdfKeyword("begincodespacerange"):
+			for {
+				lo, ok := readHex()
+				if !ok {
+					break
+				}
+				readHex()
+				lenSet[len(lo)] = true
+			}
+		case pdfKeyword("beginbfchar"):
+			for {
+				src, ok := readHex()
+				if !ok {
+					break
+				}
+				dst, _ := readHex()
+				mapping[src] = decodeUTF16BE([]byte(dst))
+				lenSet[len(src)] = true
+			}
+		case pdfKeyword("beginbfrange"):
+			for {
+				lo, ok := readHex()
+				if !ok {
+					break
+				}
+				hi, _ := readHex()
+				dst, err := lex.readObject()
+				if err != nil || len(lo) != len(hi) || len(lo) == 0 {
+					break
+				}
+				lenSet[len(lo)] = true
+				addCMapRange(mapping, lo, hi, dst)
+			}
+		}
+	}
+
+	lens := make([]int, 0, len(lenSet))
+	for n := 4; n >= 1; n-- {
+		if lenSet[n] {
+			lens = append(lens, n)
+		}
+	}
+	return mapping, lens
+}
+
+// addCMapRange expands a bfrange entry into individual code mappings
+func addCMapRange(mapping map[string]string, lo, hi string, dst any) {
+	start, end := codeValue(lo), codeValue(hi)
+	if end < start || end-start > 0xFFFF {
+		return
+	}
+
+	for code := start; code <= end; code++ {
+		src := codeBytes(code, len(lo))
+		offset := code - start
+		switch d := dst.(type) {
+		case pdfString:
+			units := utf16Units([]byte(d))
+			if len(units) == 0 {
+				continue
+			}
+			units[len(units)-1] += uint16(offset)
+			mapping[src] = string(utf16.Decode(units))
+		case []any:
+			if offset < len(d) {
+				if s, ok := d[offset].(pdfString); ok {
+					mapping[src] = decodeUTF16BE([]byte(s))
+				}
+			}
+		}
+	}
+}
+
+func codeValue(s string) int {
+	v := 0
+	for i := 0; i < len(s); i++ {
+		v = v<<8 | int(s[i])
+	}
+	return v
+}
+
+func codeBytes(v, n int) string {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return string(b)
+}
+
+func utf16Units(b []byte) []uint16 {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return units
+}
+
+// glyphNames covers the glyph names commonly found in /Differences arrays
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "parenleft": '(',
+	"parenright": ')', "asterisk": '*', "plus": '+', "comma": ',', "hyphen": '-',
+	"period": '.', "slash": '/', "zero": '0', "one": '1', "two": '2', "three": '3',
+	"four": '4', "five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"colon": ':', "semicolon": ';', "less": '<', "equal": '=', "greater": '>',
+	"question": '?', "at": '@', "bracketleft": '[', "backslash": '\\',
+	"bracketright": ']', "underscore": '_', "braceleft": '{', "bar": '|',
+	"braceright": '}', "quoteleft": '‘', "quoteright": '’', "quotedblleft": '“',
+	"quotedblright": '”', "endash": '–', "emdash": '—', "bullet": '•',
+	"ellipsis": '…', "fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ',
+}
+
+func glyphNameRune(name string) (rune, bool) {
+	if r, ok := glyphNames[name]; ok {
+		return r, true
+	}
+	if len(name) == 1 {
+		return rune(name[0]), true
+	}
+	if strings.HasPrefix(name, "uni") && len(name) == 7 {
+		if v, err := strconv.ParseUint(name[3:], 16, 32); err == nil {
+			return rune(v), true
+		}
+	}
+	return 0, false
+}
+
+// pdfTextExtractor interprets content stream operators that place text
+type pdfTextExtractor struct {
+	doc   *pdfDocument
+	out   strings.Builder
+	depth int
+}
+
+// extractPageText returns the plain text of a page
+func (d *pdfDocument) extractPageText(page pdfPage) string {
+	e := &pdfTextExtractor{doc: d}
+	e.run(d.pageContent(page), page.resources)
+	return normalizeText(e.out.String())
+}
+
+// run interprets one content stream with the given resources
+func (e *pdfTextExtractor) run(content []byte, resources pdfDict) {
+	if e.depth > 8 {
+		return
+	}
+
+	fontCache := make(map[pdfName]*pdfFont)
+	var font *pdfFont
+	var lineY float64
+	var operands []any
+
+	lex := &pdfLexer{data: content}
+	for {
+		tok, err := lex.next()
+		if err != nil {
+			break
+		}
+		op, isOp := tok.(pdfKeyword)
+		if !isOp || op == "[" || op == "<<" {
+			obj, err := lex.completeObject(tok)
+			if err != nil {
+				break
+			}
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[0].(pdfName); ok {
+					font = e.font(resources, name, fontCache)
+				}
+			}
+		case "Tj":
+			if len(operands) >= 1 {
+				e.show(font, operands[0])
+			}
+		case "'", "\"":
+			e.newline()
+			if len(operands) >= 1 {
+				e.show(font, operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				if arr, ok := operands[0].([]any); ok {
+					for _, item := range arr {
+						switch v := item.(type) {
+						case pdfString:
+							e.show(font, v)
+						case float64:
+							// Large negative adjustments are word gaps
+							if v < -200 {
+								e.space()
+							}
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, _ := operands[0].(float64)
+				ty, _ := operands[1].(float64)
+				if ty != 0 {
+					e.newline()
+					lineY += ty
+				} else if tx > 0 {
+					e.space()
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := operands[5].(float64)
+				if y != lineY {
+					e.newline()
+					lineY = y
+				} else {
+					e.space()
+				}
+			}
+		case "T*":
+			e.newline()
+		case "ET":
+			e.space()
+		case "Do":
+			if len(operands) >= 1 {
+				if name, ok := operands[0].(pdfName); ok {
+					e.form(resources, name)
+				}
+			}
+		case "BI":
+			// Skip inline image data up to EI
+			if idx := bytes.Index(content[lex.pos:], []byte("ID")); idx >= 0 {
+				lex.pos += idx + 2
+				if end := bytes.Index(content[lex.pos:], []byte("EI")); end >= 0 {
+					lex.pos += end + 2
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func (e *pdfTextExtractor) font(resources pdfDict, name pdfName, cache map[pdfName]*pdfFont) *pdfFont {
+	if f, ok := cache[name]; ok {
+		return f
+	}
+	fonts := e.doc.dictValue(resources["Font"])
+	var f *pdfFont
+	if fonts != nil {
+		if dict := e.doc.dictValue(fonts[name]); dict != nil {
+			f = e.doc.loadFont(dict)
+		}
+	}
+	cache[name] = f
+	return f
+}
+
+// form extracts text from a form XObject invoked with Do
+func (e *pdfTextExtractor) form(resources pdfDict, name pdfName) {
+	xobjects := e.doc.dictValue(resources["XObject"])
+	if xobjects == nil {
+		return
+	}
+	stream, ok := e.doc.resolve(xobjects[name]).(*pdfStream)
+	if !ok || stream.dict["Subtype"] != pdfName("Form") {
+		return
+	}
+	data, err := e.doc.decodeStream(stream)
+	if err != nil {
+		return
+	}
+	formResources := e.doc.dictValue(stream.dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+	e.depth++
+	e.run(data, formResources)
+	e.depth--
+}
+
+func (e *pdfTextExtractor) show(font *pdfFont, v any) {
+	if s, ok := v.(pdfString); ok {
+		e.out.WriteString(font.decode(string(s)))
+	}
+}
+
+func (e *pdfTextExtractor) space() {
+	s := e.out.String()
+	if len(s) > 0 && s[len(s)-1] != ' ' && s[len(s)-1] != '\n' {
+		e.out.WriteByte(' ')
+	}
+}
+
+func (e *pdfTextExtractor) newline() {
+	s := e.out.String()
+	if len(s) > 0 && s[len(s)-1] != '\n' {
+		e.out.WriteByte('\n')
+	}
+}
@@ -0,0 +1,185 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// PPTXLoader loads PowerPoint presentations (.pptx), producing one document per slide
+type PPTXLoader struct {
+	BaseLoader
+}
+
+// NewPPTXLoader creates a new PPTX loader
+func NewPPTXLoader() *PPTXLoader {
+	return &PPTXLoader{}
+}
+
+// Load reads a PPTX file and returns the text and speaker notes of each slide
+func (l *PPTXLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
+	content, err := l.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := openZipPackage(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PPTX %s: %w", filePath, err)
+	}
+
+	presentation, err := pkg.read("ppt/presentation.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PPTX %s: %w", filePath, err)
+	}
+
+	var pres struct {
+		Slides []struct {
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err := xml.Unmarshal(presentation, &pres); err != nil {
+		return nil, fmt.Errorf("failed to parse PPTX presentation %s: %w", filePath, err)
+	}
+
+	rels := pkg.relationships("ppt/presentation.xml")
+	title, author := pkg.coreProperties()
+
+	var docs []*schema.Document
+	for i, slide := range pres.Slides {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rel, ok := rels[relationshipID(slide.Attrs)]
+		if !ok {
+			continue
+		}
+		data, err := pkg.read(rel.Target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read slide %d: %w", i+1, err)
+		}
+
+		text, err := extractDrawingText(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse slide %d: %w", i+1, err)
+		}
+		notes := slideNotes(pkg, rel.Target)
+		if text == "" && notes == "" {
+			continue
+		}
+
+		body := text
+		if notes != "" {
+			body = strings.TrimSpace(body + "\n\nSpeaker notes:\n" + notes)
+		}
+
+		doc := l.CreateDocument(body, filePath)
+		doc.MetaData["format"] = "pptx"
+		doc.MetaData["slide_number"] = i + 1
+		doc.MetaData["total_slides"] = len(pres.Slides)
+		doc.MetaData["has_notes"] = notes != ""
+		setCoreMetadata(doc.MetaData, title, author)
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("file is empty: %s", filePath)
+	}
+
+	return docs, nil
+}
+
+// slideNotes returns the speaker notes attached to a slide, if any
+func slideNotes(pkg *zipPackage, slidePath string) string {
+	for _, rel := range pkg.relationships(slidePath) {
+		if !strings.HasSuffix(rel.Type, "/notesSlide") {
+			continue
+		}
+		data, err := pkg.read(rel.Target)
+		if err != nil {
+			return ""
+		}
+		notes, err := extractNotesText(data)
+		if err != nil {
+			return ""
+		}
+		return notes
+	}
+	return ""
+}
+
+// extractDrawingText returns DrawingML text, one line per a:p paragraph
+func extractDrawingText(data []byte) (string, error) {
+	return extractShapeText(data, nil)
+}
+
+// extractNotesText returns the notes body, skipping slide image and number placeholders
+func extractNotesText(data []byte) (string, error) {
+	return extractShapeText(data, map[string]bool{"sldImg": true, "sldNum": true, "hdr": true, "ftr": true, "dt": true})
+}
+
+// extractShapeText collects paragraph text of every p:sp shape whose
+// placeholder type is not listed in skip
+func extractShapeText(data []byte, skip map[string]bool) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		lines     []string
+		paragraph strings.Builder
+		shape     []string
+		skipShape bool
+	)
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "sp":
+				shape = shape[:0]
+				skipShape = false
+			case "ph":
+				skipShape = skip[attrValue(t.Attr, "type")]
+			case "p":
+				paragraph.Reset()
+			case "br":
+				paragraph.WriteByte('\n')
+			case "t":
+				var text string
+				if err := decoder.DecodeElement(&text, &t); err != nil {
+					return "", err
+				}
+				paragraph.WriteString(text)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				if text := strings.TrimSpace(paragraph.String()); text != "" {
+					shape = append(shape, text)
+				}
+				paragraph.Reset()
+			case "sp", "graphicFrame":
+				if !skipShape {
+					lines = append(lines, shape...)
+				}
+				shape = shape[:0]
+				skipShape = false
+			}
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
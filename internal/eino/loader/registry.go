@@ -0,0 +1,178 @@
+package loader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// FileInfo describes a file being matched against registered loaders
+type FileInfo struct {
+	Path      string
+	Extension string         // lower-cased, including the leading dot
+	MIME      *mimetype.MIME // sniffed content type
+	Text      bool           // content is readable text in some charset
+}
+
+// IsMIME reports whether the sniffed content type is, or derives from, one of the given types
+func (i FileInfo) IsMIME(types ...string) bool {
+	for m := i.MIME; m != nil; m = m.Parent() {
+		for _, t := range types {
+			if m.Is(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoaderMatcher decides whether a registered loader handles a file
+type LoaderMatcher func(info FileInfo) bool
+
+// LoaderConstructor builds a loader from its per-loader options
+type LoaderConstructor func(options map[string]any) (DocumentLoader, error)
+
+// MatchExtensions matches files by extension
+func MatchExtensions(exts ...string) LoaderMatcher {
+	set := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = true
+	}
+	return func(info FileInfo) bool {
+		return set[info.Extension]
+	}
+}
+
+// MatchMIME matches files by sniffed content type
+func MatchMIME(types ...string) LoaderMatcher {
+	return func(info FileInfo) bool {
+		return info.IsMIME(types...)
+	}
+}
+
+// MatchAny matches files accepted by any of the matchers
+func MatchAny(matchers ...LoaderMatcher) LoaderMatcher {
+	return func(info FileInfo) bool {
+		for _, m := range matchers {
+			if m(info) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchText matches readable text files with one of the given extensions
+func MatchText(exts ...string) LoaderMatcher {
+	byExt := MatchExtensions(exts...)
+	return func(info FileInfo) bool {
+		return info.Text && byExt(info)
+	}
+}
+
+var (
+	loaderTypesMu sync.RWMutex
+	loaderTypes   = map[string]LoaderConstructor{}
+)
+
+// RegisterLoaderType makes a loader available by name to the eino.loaders config section
+func RegisterLoaderType(name string, constructor LoaderConstructor) {
+	loaderTypesMu.Lock()
+	defer loaderTypesMu.Unlock()
+	loaderTypes[strings.ToLower(name)] = constructor
+}
+
+// LoaderTypes returns the names of all registered loader types
+func LoaderTypes() []string {
+	loaderTypesMu.RLock()
+	defer loaderTypesMu.RUnlock()
+
+	names := make([]string, 0, len(loaderTypes))
+	for name := range loaderTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupLoaderType(name string) (LoaderConstructor, bool) {
+	loaderTypesMu.RLock()
+	defer loaderTypesMu.RUnlock()
+	constructor, ok := loaderTypes[strings.ToLower(name)]
+	return constructor, ok
+}
+
+// withoutOptions adapts a plain constructor to LoaderConstructor, rejecting any options
+func withoutOptions[T DocumentLoader](constructor func() T) LoaderConstructor {
+	return func(options map[string]any) (DocumentLoader, error) {
+		if len(options) > 0 {
+			return nil, fmt.Errorf("loader does not accept options")
+		}
+		return constructor(), nil
+	}
+}
+
+// decodeOptions decodes loader options into a typed options struct, rejecting unknown keys
+func decodeOptions(options map[string]any, target any) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           target,
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(options); err != nil {
+		return fmt.Errorf("invalid loader options: %w", err)
+	}
+	return nil
+}
+
+func newMarkdownLoaderFromOptions(options map[string]any) (DocumentLoader, error) {
+	var opts MarkdownOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	return NewMarkdownLoaderWithOptions(opts)
+}
+
+func newStructuredLoaderFromOptions(format string) LoaderConstructor {
+	return func(options map[string]any) (DocumentLoader, error) {
+		opts := StructuredOptions{Format: format}
+		if err := decodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		return NewStructuredLoaderWithOptions(opts)
+	}
+}
+
+func newArchiveLoaderFromOptions(options map[string]any) (DocumentLoader, error) {
+	var opts ArchiveOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	return NewArchiveLoaderWithOptions(opts)
+}
+
+func init() {
+	RegisterLoaderType("text", withoutOptions(NewTextLoader))
+	RegisterLoaderType("markdown", newMarkdownLoaderFromOptions)
+	RegisterLoaderType("html", withoutOptions(NewHTMLLoader))
+	RegisterLoaderType("csv", newStructuredLoaderFromOptions(StructuredCSV))
+	RegisterLoaderType("json", newStructuredLoaderFromOptions(StructuredJSON))
+	RegisterLoaderType("jsonl", newStructuredLoaderFromOptions(StructuredJSONL))
+	RegisterLoaderType("pdf", withoutOptions(NewPDFLoader))
+	RegisterLoaderType("docx", withoutOptions(NewDOCXLoader))
+	RegisterLoaderType("xlsx", withoutOptions(NewXLSXLoader))
+	RegisterLoaderType("pptx", withoutOptions(NewPPTXLoader))
+	RegisterLoaderType("epub", withoutOptions(NewEPUBLoader))
+	RegisterLoaderType("archive", newArchiveLoaderFromOptions)
+}
@@ -0,0 +1,363 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Structured data formats
+const (
+	StructuredCSV   = "csv"
+	StructuredJSON  = "json"
+	StructuredJSONL = "jsonl"
+)
+
+// StructuredOptions configures the structured data loader
+type StructuredOptions struct {
+	Format         string   `mapstructure:"format"`          // csv, json or jsonl; detected from the extension when empty
+	Template       string   `mapstructure:"template"`        // text/template rendering a record into document content; a missing field is an error
+	MetadataFields []string `mapstructure:"metadata_fields"` // record fields copied into document metadata
+	Delimiter      string   `mapstructure:"delimiter"`       // CSV field delimiter, defaults to comma (tab for .tsv)
+	RecordsPath    string   `mapstructure:"records_path"`    // dotted path to the record array inside a JSON document
+}
+
+// StructuredLoader loads CSV, JSON and JSONL files, producing one document per record
+type StructuredLoader struct {
+	BaseLoader
+	options  StructuredOptions
+	template *template.Template
+}
+
+// structuredRecord is a record with its fields in source order
+type structuredRecord struct {
+	keys   []string
+	values map[string]any
+}
+
+// NewStructuredLoader creates a structured data loader that renders every field of a record
+func NewStructuredLoader() *StructuredLoader {
+	loader, _ := NewStructuredLoaderWithOptions(StructuredOptions{})
+	return loader
+}
+
+// NewStructuredLoaderWithOptions creates a structured data loader with the given options
+func NewStructuredLoaderWithOptions(options StructuredOptions) (*StructuredLoader, error) {
+	switch options.Format {
+	case "", StructuredCSV, StructuredJSON, StructuredJSONL:
+	default:
+		return nil, fmt.Errorf("unknown structured format: %s", options.Format)
+	}
+	if options.Delimiter != "" && utf8.RuneCountInString(options.Delimiter) != 1 {
+		return nil, fmt.Errorf("delimiter must be a single character: %q", options.Delimiter)
+	}
+
+	l := &StructuredLoader{options: options}
+	if options.Template != "" {
+		// A missing field would otherwise be rendered as "<no value>"
+		tmpl, err := template.New("record").Option("missingkey=error").Parse(options.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse record template: %w", err)
+		}
+		l.template = tmpl
+	}
+	return l, nil
+}
+
+// Load reads a structured data file and returns a document for each record
+func (l *StructuredLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
+	content, charset, err := l.ReadText(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	format := l.format(filePath)
+
+	var records []structuredRecord
+	switch format {
+	case StructuredCSV:
+		records, err = l.readCSV(content, filePath)
+	case StructuredJSON:
+		records, err = readJSONRecords(content, l.options.RecordsPath)
+	case StructuredJSONL:
+		records, err = readJSONLRecords(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s %s: %w", strings.ToUpper(format), filePath, err)
+	}
+
+	var docs []*schema.Document
+	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		text, err := l.render(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render record %d of %s: %w", i+1, filePath, err)
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		doc := l.CreateDocument(text, filePath)
+		for _, field := range l.options.MetadataFields {
+			value, ok := record.values[field]
+			if !ok || value == nil {
+				continue
+			}
+			if _, reserved := doc.MetaData[field]; !reserved {
+				doc.MetaData[field] = value
+			}
+		}
+		doc.MetaData["format"] = format
+		doc.MetaData["row_number"] = i + 1
+		doc.MetaData["total_rows"] = len(records)
+		setCharset(doc.MetaData, charset)
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("file is empty: %s", filePath)
+	}
+
+	return docs, nil
+}
+
+// format returns the configured format, or the one implied by the file extension
+func (l *StructuredLoader) format(filePath string) string {
+	if l.options.Format != "" {
+		return l.options.Format
+	}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return StructuredJSON
+	case ".jsonl", ".ndjson":
+		return StructuredJSONL
+	default:
+		return StructuredCSV
+	}
+}
+
+// render turns a record into document content
+func (l *StructuredLoader) render(record structuredRecord) (string, error) {
+	if l.template == nil {
+		var sb strings.Builder
+		for _, key := range record.keys {
+			value := formatFieldValue(record.values[key])
+			if value == "" {
+				continue
+			}
+			fmt.Fprintf(&sb, "%s: %s\n", key, value)
+		}
+		return strings.TrimSpace(sb.String()), nil
+	}
+
+	var buf bytes.Buffer
+	if err := l.template.Execute(&buf, record.values); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// readCSV reads CSV records keyed by the header row
+func (l *StructuredLoader) readCSV(content, filePath string) ([]structuredRecord, error) {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if l.options.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(l.options.Delimiter)
+	} else if strings.EqualFold(filepath.Ext(filePath), ".tsv") {
+		reader.Comma = '\t'
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+		if header[i] == "" {
+			header[i] = fmt.Sprintf("column_%d", i+1)
+		}
+	}
+
+	known := make(map[string]bool, len(header))
+	for _, name := range header {
+		known[name] = true
+	}
+	for _, field := range l.options.MetadataFields {
+		if !known[field] {
+			return nil, fmt.Errorf("metadata field %q is not a column", field)
+		}
+	}
+
+	var records []structuredRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := structuredRecord{keys: header, values: make(map[string]any, len(header))}
+		empty := true
+		for i, name := range header {
+			value := ""
+			if i < len(row) {
+				value = strings.TrimSpace(row[i])
+			}
+			if value != "" {
+				empty = false
+			}
+			record.values[name] = value
+		}
+		if !empty {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// readJSONRecords reads the records of a JSON document: an array of objects,
+// a single object, or an array found at recordsPath
+func readJSONRecords(content, recordsPath string) ([]structuredRecord, error) {
+	data := json.RawMessage(content)
+	if recordsPath != "" {
+		for _, key := range strings.Split(recordsPath, ".") {
+			var object map[string]json.RawMessage
+			if err := json.Unmarshal(data, &object); err != nil {
+				return nil, fmt.Errorf("records_path %q: %w", recordsPath, err)
+			}
+			next, ok := object[key]
+			if !ok {
+				return nil, fmt.Errorf("records_path %q: key %q not found", recordsPath, key)
+			}
+			data = next
+		}
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+	if trimmed[0] != '[' {
+		record, err := decodeJSONRecord(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return []structuredRecord{record}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(trimmed, &items); err != nil {
+		return nil, err
+	}
+
+	records := make([]structuredRecord, 0, len(items))
+	for i, item := range items {
+		record, err := decodeJSONRecord(item)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// readJSONLRecords reads one JSON record per non-empty line
+func readJSONLRecords(content string) ([]structuredRecord, error) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxZipPartSize)
+
+	var records []structuredRecord
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		record, err := decodeJSONRecord(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// decodeJSONRecord decodes a JSON value into a record, keeping the key order of
+// objects. Scalars and arrays become a record with a single "value" field.
+func decodeJSONRecord(data []byte) (structuredRecord, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // keep large identifiers exact
+	if err := decoder.Decode(&value); err != nil {
+		return structuredRecord{}, err
+	}
+	values, ok := value.(map[string]any)
+	if !ok {
+		if value == nil {
+			return structuredRecord{}, nil
+		}
+		return structuredRecord{keys: []string{"value"}, values: map[string]any{"value": value}}, nil
+	}
+
+	decoder = json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return structuredRecord{}, err
+	}
+	keys := make([]string, 0, len(values))
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return structuredRecord{}, err
+		}
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return structuredRecord{}, err
+		}
+		if key, ok := tok.(string); ok && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return structuredRecord{keys: keys, values: values}, nil
+}
+
+// formatFieldValue renders a field value for the default record layout
+func formatFieldValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -19,7 +19,7 @@ func NewTextLoader() *TextLoader {
 
 // Load reads a text file and returns a document
 func (l *TextLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
-	content, err := l.ReadFile(filePath)
+	content, charset, err := l.ReadText(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -28,6 +28,7 @@ func (l *TextLoader) Load(ctx context.Context, filePath string) ([]*schema.Docum
 		return nil, fmt.Errorf("file is empty: %s", filePath)
 	}
 
-	doc := l.CreateDocument(string(content), filePath)
+	doc := l.CreateDocument(content, filePath)
+	setCharset(doc.MetaData, charset)
 	return []*schema.Document{doc}, nil
 }
\ No newline at end of file
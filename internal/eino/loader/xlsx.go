@@ -0,0 +1,281 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// XLSXLoader loads Excel workbooks (.xlsx), producing one document per sheet
+type XLSXLoader struct {
+	BaseLoader
+}
+
+// NewXLSXLoader creates a new XLSX loader
+func NewXLSXLoader() *XLSXLoader {
+	return &XLSXLoader{}
+}
+
+// Load reads an XLSX file and renders each non-empty sheet as a text table
+func (l *XLSXLoader) Load(ctx context.Context, filePath string) ([]*schema.Document, error) {
+	content, err := l.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := openZipPackage(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX %s: %w", filePath, err)
+	}
+
+	workbook, err := pkg.read("xl/workbook.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX %s: %w", filePath, err)
+	}
+
+	var wb struct {
+		Sheets []struct {
+			Name  string     `xml:"name,attr"`
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(workbook, &wb); err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX workbook %s: %w", filePath, err)
+	}
+
+	sharedStrings, err := readSharedStrings(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX shared strings %s: %w", filePath, err)
+	}
+
+	rels := pkg.relationships("xl/workbook.xml")
+	title, author := pkg.coreProperties()
+
+	var docs []*schema.Document
+	for i, sheet := range wb.Sheets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rel, ok := rels[relationshipID(sheet.Attrs)]
+		if !ok {
+			continue
+		}
+		data, err := pkg.read(rel.Target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %q: %w", sheet.Name, err)
+		}
+
+		rows, err := readSheetRows(data, sharedStrings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sheet %q: %w", sheet.Name, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		doc := l.CreateDocument(renderTextTable(sheet.Name, rows), filePath)
+		doc.MetaData["format"] = "xlsx"
+		doc.MetaData["sheet_name"] = sheet.Name
+		doc.MetaData["sheet_index"] = i
+		doc.MetaData["row_count"] = len(rows)
+		setCoreMetadata(doc.MetaData, title, author)
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("file is empty: %s", filePath)
+	}
+
+	return docs, nil
+}
+
+// readSharedStrings returns the workbook's shared string table
+func readSharedStrings(pkg *zipPackage) ([]string, error) {
+	if !pkg.has("xl/sharedStrings.xml") {
+		return nil, nil
+	}
+	data, err := pkg.read("xl/sharedStrings.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	var current strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				current.Reset()
+			case "rPh":
+				// Phonetic hints are not part of the cell text
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+			case "t":
+				var text string
+				if err := decoder.DecodeElement(&text, &t); err != nil {
+					return nil, err
+				}
+				current.WriteString(text)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "si" {
+				result = append(result, current.String())
+			}
+		}
+	}
+	return result, nil
+}
+
+// readSheetRows returns the non-empty rows of a worksheet, with cells placed
+// by their column reference so that sparse rows keep their alignment
+func readSheetRows(data []byte, sharedStrings []string) ([][]string, error) {
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					Text string `xml:"t"`
+					Runs []struct {
+						Text string `xml:"t"`
+					} `xml:"r"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(data, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, r := range sheet.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := columnIndex(c.Ref)
+			if col < 0 {
+				col = i
+			}
+			if col >= maxColumns {
+				// Beyond XFD, only a crafted file gets here
+				continue
+			}
+
+			var value string
+			switch c.Type {
+			case "s":
+				if idx, err := strconv.Atoi(c.Value); err == nil && idx >= 0 && idx < len(sharedStrings) {
+					value = sharedStrings[idx]
+				}
+			case "inlineStr":
+				value = c.Inline.Text
+				for _, run := range c.Inline.Runs {
+					value += run.Text
+				}
+			case "b":
+				value = map[string]string{"0": "FALSE", "1": "TRUE"}[c.Value]
+			default:
+				value = c.Value
+			}
+
+			// Styled empty cells may sit far to the right, rows only
+			// extend to their last value
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			row[col] = value
+		}
+
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// maxColumns is the number of columns of a worksheet, A to XFD
+const maxColumns = 16384
+
+// columnIndex converts a cell reference such as "AB12" to a zero-based
+// column. Columns beyond XFD are reported as maxColumns.
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		if col > maxColumns {
+			return maxColumns
+		}
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+// renderTextTable renders rows as a pipe table, treating the first row as the
+// header. Columns empty in every row are left out.
+func renderTextTable(name string, rows [][]string) string {
+	var used []bool
+	for _, row := range rows {
+		for col, cell := range row {
+			if cell == "" {
+				continue
+			}
+			for len(used) <= col {
+				used = append(used, false)
+			}
+			used[col] = true
+		}
+	}
+	var columns []int
+	for col, ok := range used {
+		if ok {
+			columns = append(columns, col)
+		}
+	}
+	width := len(columns)
+
+	var b strings.Builder
+	if name != "" {
+		b.WriteString("## " + name + "\n\n")
+	}
+	for i, row := range rows {
+		cells := make([]string, width)
+		for j, col := range columns {
+			if col < len(row) {
+				cells[j] = strings.ReplaceAll(row[col], "\n", " ")
+			}
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 && len(rows) > 1 {
+			b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,174 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zibianqu/eino_study/internal/app/repository"
+	"github.com/zibianqu/eino_study/internal/config"
+)
+
+// DefaultReloadInterval is how often database templates are re-read
+const DefaultReloadInterval = time.Minute
+
+// ErrNotFound is returned for an unknown prompt ID or version
+var ErrNotFound = errors.New("prompt template not found")
+
+// Store holds the versions of every prompt: the built-in ones, those in the
+// config and those in the prompt_templates table. Database templates are
+// re-read periodically, so new versions take effect without a restart; on
+// the same ID and version they take precedence over the config.
+type Store struct {
+	repo           repository.PromptTemplateRepository
+	defaultID      string
+	static         []*Template
+	reloadInterval time.Duration
+
+	mu        sync.RWMutex
+	templates map[string][]*Template // By ID, ascending versions
+	loadedAt  time.Time
+}
+
+// NewStore creates a prompt store from the config and, if repo is not nil,
+// the database
+func NewStore(cfg *config.PromptConfig, repo repository.PromptTemplateRepository) (*Store, error) {
+	s := &Store{
+		repo:           repo,
+		defaultID:      cfg.Default,
+		static:         append([]*Template{}, builtinTemplates...),
+		reloadInterval: cfg.ReloadInterval,
+	}
+	if s.defaultID == "" {
+		s.defaultID = DefaultID
+	}
+	if s.reloadInterval == 0 {
+		s.reloadInterval = DefaultReloadInterval
+	}
+
+	for _, tc := range cfg.Templates {
+		t := &Template{
+			ID:       tc.ID,
+			Version:  tc.Version,
+			Format:   tc.Format,
+			System:   tc.System,
+			User:     tc.User,
+			Document: tc.Document,
+			NoAnswer: tc.NoAnswer,
+		}
+		if err := t.compile(); err != nil {
+			return nil, fmt.Errorf("invalid prompt template in config: %w", err)
+		}
+		s.static = append(s.static, t)
+	}
+	for _, t := range builtinTemplates {
+		if err := t.compile(); err != nil {
+			return nil, fmt.Errorf("invalid built-in prompt template: %w", err)
+		}
+	}
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	if _, ok := s.templates[s.defaultID]; !ok {
+		return nil, fmt.Errorf("default prompt %s is not defined", s.defaultID)
+	}
+	return s, nil
+}
+
+// Get returns a version of a prompt, its latest version if version is 0 and
+// the default prompt if id is empty
+func (s *Store) Get(id string, version int) (*Template, error) {
+	if id == "" {
+		id = s.defaultID
+	}
+	s.refresh()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := s.templates[id]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if version == 0 {
+		return versions[len(versions)-1], nil
+	}
+	for _, t := range versions {
+		if t.Version == version {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s@%d", ErrNotFound, id, version)
+}
+
+// refresh re-reads the database templates once reloadInterval has passed,
+// keeping the loaded ones if that fails
+func (s *Store) refresh() {
+	if s.repo == nil || s.reloadInterval < 0 {
+		return
+	}
+	s.mu.RLock()
+	stale := time.Since(s.loadedAt) >= s.reloadInterval
+	s.mu.RUnlock()
+	if !stale {
+		return
+	}
+
+	if err := s.reload(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// reload merges the database templates over the static ones
+func (s *Store) reload() error {
+	byKey := make(map[string]*Template)
+	key := func(t *Template) string { return fmt.Sprintf("%s@%d", t.ID, t.Version) }
+	for _, t := range s.static {
+		byKey[key(t)] = t
+	}
+
+	if s.repo != nil {
+		rows, err := s.repo.List()
+		if err != nil {
+			s.mu.Lock()
+			s.loadedAt = time.Now() // Retry after the interval, not on every request
+			s.mu.Unlock()
+			return fmt.Errorf("failed to load prompt templates: %w", err)
+		}
+		for _, row := range rows {
+			t := &Template{
+				ID:       row.PromptID,
+				Version:  row.Version,
+				Format:   row.Format,
+				System:   row.System,
+				User:     row.User,
+				Document: row.Document,
+				NoAnswer: row.NoAnswer,
+			}
+			// A broken row must not take the other prompts down
+			if err := t.compile(); err != nil {
+				log.Printf("Warning: skipping invalid prompt template in database: %v", err)
+				continue
+			}
+			byKey[key(t)] = t
+		}
+	}
+
+	templates := make(map[string][]*Template)
+	for _, t := range byKey {
+		templates[t.ID] = append(templates[t.ID], t)
+	}
+	for _, versions := range templates {
+		sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	}
+
+	s.mu.Lock()
+	s.templates = templates
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
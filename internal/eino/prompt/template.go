@@ -0,0 +1,144 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	einoprompt "github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Template syntaxes
+const (
+	FormatFString    = "fstring"     // {query}, the default
+	FormatGoTemplate = "go_template" // {{.query}}
+	FormatJinja2     = "jinja2"      // {{ query }}
+)
+
+var formatTypes = map[string]schema.FormatType{
+	FormatFString:    schema.FString,
+	FormatGoTemplate: schema.GoTemplate,
+	FormatJinja2:     schema.Jinja2,
+}
+
+// DefaultID is the ID of the built-in prompt, used when neither the request
+// nor the config selects one
+const DefaultID = "default"
+
+// Template is one version of a named RAG prompt. The document template is
+// rendered for every retrieved document with index, content, doc_id and
+// heading_path; the results are joined into context, which the system and
+// user templates receive together with query.
+type Template struct {
+	ID       string `json:"id"`
+	Version  int    `json:"version"`
+	Format   string `json:"format"`
+	System   string `json:"system"`
+	User     string `json:"user"`
+	Document string `json:"document"`
+	NoAnswer string `json:"no_answer"` // Reply when no document was retrieved
+
+	formatType schema.FormatType
+	chat       einoprompt.ChatTemplate
+	document   schema.MessagesTemplate
+}
+
+// builtinTemplates are always available, config and database templates add
+// versions or replace them
+var builtinTemplates = []*Template{
+	{
+		ID:       DefaultID,
+		Version:  1,
+		Format:   FormatFString,
+		System:   "你是一个专业的知识库助手。请根据提供的上下文信息回答用户的问题。如果上下文中没有相关信息，请明确说明。",
+		User:     "基于以下上下文信息回答问题。请确保答案准确、完整，并尽可能引用上下文中的具体内容。\n\n上下文信息：\n{context}\n\n问题：{query}\n\n回答：",
+		Document: "[文档 {index}]\n{content}\n\n",
+		NoAnswer: "抱歉，我没有找到相关的文档来回答您的问题。",
+	},
+}
+
+// compile checks the templates and prepares them for Render
+func (t *Template) compile() error {
+	if t.ID == "" {
+		return fmt.Errorf("prompt template without id")
+	}
+	if t.Version <= 0 {
+		return fmt.Errorf("prompt %s: version must be positive", t.ID)
+	}
+	if t.User == "" {
+		return fmt.Errorf("prompt %s@%d: user template is empty", t.ID, t.Version)
+	}
+	if t.Format == "" {
+		t.Format = FormatFString
+	}
+	formatType, ok := formatTypes[t.Format]
+	if !ok {
+		return fmt.Errorf("prompt %s@%d: unsupported format %s", t.ID, t.Version, t.Format)
+	}
+	t.formatType = formatType
+
+	var messages []schema.MessagesTemplate
+	if t.System != "" {
+		messages = append(messages, schema.SystemMessage(t.System))
+	}
+	messages = append(messages, schema.UserMessage(t.User))
+	t.chat = einoprompt.FromMessages(formatType, messages...)
+	if t.Document != "" {
+		t.document = schema.UserMessage(t.Document)
+	}
+
+	// Render once so that syntax errors and unknown variables fail at load
+	sample := []*schema.Document{{ID: "sample", Content: "sample", MetaData: map[string]any{}}}
+	if _, err := t.Render(context.Background(), "sample", sample); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Render formats the messages sent to the LLM for query and the retrieved docs
+func (t *Template) Render(ctx context.Context, query string, docs []*schema.Document) ([]*schema.Message, error) {
+	docContext, err := t.buildContext(ctx, docs)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := t.chat.Format(ctx, map[string]any{
+		"query":   query,
+		"context": docContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to format prompt %s@%d: %w", t.ID, t.Version, err)
+	}
+	return messages, nil
+}
+
+// buildContext renders the document template for every document
+func (t *Template) buildContext(ctx context.Context, docs []*schema.Document) (string, error) {
+	var builder strings.Builder
+
+	for i, doc := range docs {
+		if t.document == nil {
+			builder.WriteString(doc.Content)
+			builder.WriteString("\n\n")
+			continue
+		}
+
+		docID, _ := doc.MetaData["doc_id"].(string)
+		headingPath, _ := doc.MetaData["heading_path"].(string)
+		rendered, err := t.document.Format(ctx, map[string]any{
+			"index":        i + 1,
+			"content":      doc.Content,
+			"doc_id":       docID,
+			"heading_path": headingPath,
+		}, t.formatType)
+		if err != nil {
+			return "", fmt.Errorf("failed to format document of prompt %s@%d: %w", t.ID, t.Version, err)
+		}
+		for _, msg := range rendered {
+			builder.WriteString(msg.Content)
+		}
+	}
+
+	return builder.String(), nil
+}
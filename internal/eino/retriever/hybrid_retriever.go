@@ -0,0 +1,148 @@
+package retriever
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/zibianqu/eino_study/internal/app/repository"
+	"github.com/zibianqu/eino_study/internal/eino/embedding"
+	"github.com/zibianqu/eino_study/internal/model"
+	"golang.org/x/sync/errgroup"
+)
+
+// HybridRetriever retrieves relevant documents using both vector similarity
+// and full-text search, so that exact identifiers such as error codes are
+// found even when their embedding is not close to the query's
+type HybridRetriever struct {
+	chunkRepo     repository.ChunkRepository
+	embedding     *embedding.EmbeddingClient
+	topK          int
+	threshold     float64
+	vectorWeight  float64
+	lexicalWeight float64
+	rrfK          int
+}
+
+// NewHybridRetriever creates a new hybrid retriever. The rankings of the two
+// searches are fused with weighted reciprocal rank fusion, see fuseRankings.
+// Negative weights default to 1; a weight of 0 leaves that search out, but
+// not both.
+func NewHybridRetriever(
+	chunkRepo repository.ChunkRepository,
+	embedding *embedding.EmbeddingClient,
+	topK int,
+	threshold float64,
+	vectorWeight float64,
+	lexicalWeight float64,
+	rrfK int,
+) (*HybridRetriever, error) {
+	if topK <= 0 {
+		topK = 5
+	}
+	if threshold <= 0 {
+		threshold = 0.7
+	}
+	if vectorWeight < 0 {
+		vectorWeight = 1
+	}
+	if lexicalWeight < 0 {
+		lexicalWeight = 1
+	}
+	if vectorWeight == 0 && lexicalWeight == 0 {
+		return nil, fmt.Errorf("vector_weight and lexical_weight cannot both be 0")
+	}
+	if rrfK <= 0 {
+		rrfK = 60
+	}
+
+	return &HybridRetriever{
+		chunkRepo:     chunkRepo,
+		embedding:     embedding,
+		topK:          topK,
+		threshold:     threshold,
+		vectorWeight:  vectorWeight,
+		lexicalWeight: lexicalWeight,
+		rrfK:          rrfK,
+	}, nil
+}
+
+// Retrieve retrieves relevant documents for a query. The similarity
+// threshold only applies to the vector search.
+func (r *HybridRetriever) Retrieve(ctx context.Context, query string, opts ...Option) ([]*schema.Document, error) {
+	if query == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+
+	o := applyOptions(r.topK, r.threshold, opts)
+	limit := o.topK * childOverfetch
+
+	var vectorChunks, lexicalChunks []*model.DocumentChunk
+	g, gctx := errgroup.WithContext(ctx)
+	if r.vectorWeight > 0 {
+		g.Go(func() error {
+			queryVector, err := r.embedding.EmbedText(gctx, query)
+			if err != nil {
+				return fmt.Errorf("failed to generate query embedding: %w", err)
+			}
+			vectorChunks, err = r.chunkRepo.SearchSimilar(vectorToString(queryVector), limit, o.threshold, o.filter)
+			if err != nil {
+				return fmt.Errorf("failed to search similar chunks: %w", err)
+			}
+			return nil
+		})
+	}
+	if r.lexicalWeight > 0 {
+		g.Go(func() error {
+			var err error
+			lexicalChunks, err = r.chunkRepo.SearchLexical(query, limit, o.filter)
+			if err != nil {
+				return fmt.Errorf("failed to search chunks by text: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	chunks, scores := fuseRankings(r.rrfK,
+		ranking{chunks: vectorChunks, weight: r.vectorWeight},
+		ranking{chunks: lexicalChunks, weight: r.lexicalWeight},
+	)
+	chunks = chunks[:min(len(chunks), limit)]
+
+	parents, err := loadParents(r.chunkRepo, chunks)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDocuments(chunks, parents, o.topK, scores), nil
+}
+
+// ranking is a search result list, best first, and its weight in the fusion
+type ranking struct {
+	chunks []*model.DocumentChunk
+	weight float64
+}
+
+// fuseRankings merges rankings by reciprocal rank fusion: a chunk scores
+// weight / (k + rank) for each list it is in, ranks counting from 1. It
+// returns the chunks by descending score, ties in order of first
+// appearance, and the scores keyed by chunk ID.
+func fuseRankings(k int, rankings ...ranking) ([]*model.DocumentChunk, map[int]float64) {
+	var fused []*model.DocumentChunk
+	scores := make(map[int]float64)
+	for _, r := range rankings {
+		for i, chunk := range r.chunks {
+			if _, ok := scores[chunk.ID]; !ok {
+				fused = append(fused, chunk)
+			}
+			scores[chunk.ID] += r.weight / float64(k+i+1)
+		}
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool { return scores[fused[i].ID] > scores[fused[j].ID] })
+	return fused, scores
+}
@@ -0,0 +1,191 @@
+package retriever
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/zibianqu/eino_study/internal/app/repository"
+	"github.com/zibianqu/eino_study/internal/config"
+	"github.com/zibianqu/eino_study/internal/eino/embedding"
+	"github.com/zibianqu/eino_study/internal/model"
+)
+
+// Retriever finds the documents relevant to a query
+type Retriever interface {
+	Retrieve(ctx context.Context, query string, opts ...Option) ([]*schema.Document, error)
+}
+
+// Retriever types
+const (
+	TypeVector = "vector"
+	TypeHybrid = "hybrid"
+)
+
+// NewRetriever creates the retriever selected by cfg.Type
+func NewRetriever(cfg *config.RetrieverConfig, chunkRepo repository.ChunkRepository, embeddingClient *embedding.EmbeddingClient) (Retriever, error) {
+	switch cfg.Type {
+	case "", TypeVector:
+		return NewVectorRetriever(chunkRepo, embeddingClient, cfg.TopK, cfg.SimilarityThreshold), nil
+	case TypeHybrid:
+		return NewHybridRetriever(
+			chunkRepo,
+			embeddingClient,
+			cfg.TopK,
+			cfg.SimilarityThreshold,
+			weightOrDefault(cfg.VectorWeight),
+			weightOrDefault(cfg.LexicalWeight),
+			cfg.RRFK,
+		)
+	default:
+		return nil, fmt.Errorf("unsupported retriever type: %s", cfg.Type)
+	}
+}
+
+// weightOrDefault returns the configured weight of a result list, or -1 for
+// the default if unset
+func weightOrDefault(weight *float64) float64 {
+	if weight == nil {
+		return -1
+	}
+	return *weight
+}
+
+// Option overrides a retrieval parameter for one query
+type Option func(*options)
+
+type options struct {
+	topK      int
+	threshold float64
+	filter    *repository.ChunkFilter
+}
+
+// WithTopK sets the number of documents to return, if positive
+func WithTopK(topK int) Option {
+	return func(o *options) {
+		if topK > 0 {
+			o.topK = topK
+		}
+	}
+}
+
+// WithThreshold sets the minimum cosine similarity of the returned chunks
+func WithThreshold(threshold float64) Option {
+	return func(o *options) {
+		o.threshold = threshold
+	}
+}
+
+// WithFilter restricts the search to chunks matching filter
+func WithFilter(filter *repository.ChunkFilter) Option {
+	return func(o *options) {
+		o.filter = filter
+	}
+}
+
+// applyOptions returns the parameters of one query, the given defaults
+// overridden by opts
+func applyOptions(topK int, threshold float64, opts []Option) *options {
+	o := &options{topK: topK, threshold: threshold}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// childOverfetch is how many chunks are searched per result
+const childOverfetch = 3
+
+// loadParents fetches the parents of the child chunks, keyed by ID
+func loadParents(chunkRepo repository.ChunkRepository, chunks []*model.DocumentChunk) (map[int]*model.DocumentChunk, error) {
+	var ids []int
+	for _, chunk := range chunks {
+		if chunk.ParentID != nil {
+			ids = append(ids, *chunk.ParentID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	parents, err := chunkRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load parent chunks: %w", err)
+	}
+
+	byID := make(map[int]*model.DocumentChunk, len(parents))
+	for _, parent := range parents {
+		byID[parent.ID] = parent
+	}
+	return byID, nil
+}
+
+// toDocuments converts ranked chunks to at most topK documents, replacing
+// children by their parent and dropping repeats. With scores, each document
+// carries the score of the chunk that matched.
+func toDocuments(chunks []*model.DocumentChunk, parents map[int]*model.DocumentChunk, topK int, scores map[int]float64) []*schema.Document {
+	docs := make([]*schema.Document, 0, topK)
+	seen := make(map[int]bool)
+	for _, chunk := range chunks {
+		if len(docs) >= topK {
+			break
+		}
+
+		matched := chunk
+		if chunk.ParentID != nil {
+			if parent, ok := parents[*chunk.ParentID]; ok {
+				chunk = parent
+			}
+		}
+		if seen[chunk.ID] {
+			continue
+		}
+		seen[chunk.ID] = true
+
+		doc := chunkToDocument(chunk)
+		if matched != chunk {
+			doc.MetaData["matched_chunk_id"] = matched.ID
+			doc.MetaData["matched_chunk_index"] = matched.ChunkIndex
+		}
+		if scores != nil {
+			doc.MetaData["score"] = scores[matched.ID]
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// chunkToDocument converts a stored chunk to a document with its metadata
+func chunkToDocument(chunk *model.DocumentChunk) *schema.Document {
+	metadata := make(map[string]any)
+	if chunk.Metadata != "" {
+		// Metadata written by the splitter, e.g. the heading path of the chunk
+		_ = json.Unmarshal([]byte(chunk.Metadata), &metadata)
+	}
+	metadata["doc_id"] = chunk.DocID
+	metadata["chunk_index"] = chunk.ChunkIndex
+	metadata["chunk_id"] = chunk.ID
+
+	return &schema.Document{
+		Content:  chunk.Content,
+		MetaData: metadata,
+	}
+}
+
+// vectorToString converts float32 slice to string format for pgvector
+func vectorToString(vector []float32) string {
+	if len(vector) == 0 {
+		return "[]"
+	}
+
+	result := "["
+	for i, v := range vector {
+		if i > 0 {
+			result += ","
+		}
+		result += fmt.Sprintf("%f", v)
+	}
+	result += "]"
+	return result
+}
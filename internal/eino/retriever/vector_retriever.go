@@ -40,11 +40,13 @@ func NewVectorRetriever(
 }
 
 // Retrieve retrieves relevant documents for a query
-func (r *VectorRetriever) Retrieve(ctx context.Context, query string) ([]*schema.Document, error) {
+func (r *VectorRetriever) Retrieve(ctx context.Context, query string, opts ...Option) ([]*schema.Document, error) {
 	if query == "" {
 		return nil, fmt.Errorf("query is empty")
 	}
 
+	o := applyOptions(r.topK, r.threshold, opts)
+
 	// Generate embedding for query
 	queryVector, err := r.embedding.EmbedText(ctx, query)
 	if err != nil {
@@ -54,42 +56,17 @@ func (r *VectorRetriever) Retrieve(ctx context.Context, query string) ([]*schema
 	// Convert vector to string format for pgvector
 	vectorStr := vectorToString(queryVector)
 
-	// Search similar chunks
-	chunks, err := r.chunkRepo.SearchSimilar(vectorStr, r.topK, r.threshold)
+	// Search similar chunks. Several children can share a parent, so more are
+	// fetched than needed to still fill topK after deduplication.
+	chunks, err := r.chunkRepo.SearchSimilar(vectorStr, o.topK*childOverfetch, o.threshold, o.filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search similar chunks: %w", err)
 	}
 
-	// Convert chunks to documents
-	docs := make([]*schema.Document, 0, len(chunks))
-	for _, chunk := range chunks {
-		doc := &schema.Document{
-			Content: chunk.Content,
-			MetaData: map[string]any{
-				"doc_id":      chunk.DocID,
-				"chunk_index": chunk.ChunkIndex,
-				"chunk_id":    chunk.ID,
-			},
-		}
-		docs = append(docs, doc)
+	parents, err := loadParents(r.chunkRepo, chunks)
+	if err != nil {
+		return nil, err
 	}
 
-	return docs, nil
+	return toDocuments(chunks, parents, o.topK, nil), nil
 }
-
-// vectorToString converts float32 slice to string format for pgvector
-func vectorToString(vector []float32) string {
-	if len(vector) == 0 {
-		return "[]"
-	}
-
-	result := "["
-	for i, v := range vector {
-		if i > 0 {
-			result += ","
-		}
-		result += fmt.Sprintf("%f", v)
-	}
-	result += "]"
-	return result
-}
\ No newline at end of file
@@ -0,0 +1,71 @@
+package splitter
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Location metadata keys. Offsets count runes from the start of the source
+// file, end exclusive; lines are 1-based and inclusive.
+const (
+	MetaStartOffset = "start_offset"
+	MetaEndOffset   = "end_offset"
+	MetaStartLine   = "start_line"
+	MetaEndLine     = "end_line"
+)
+
+// locateChunks records where each chunk lies in the source file. Chunks are
+// found in doc.Content in order; the position of doc itself within the file,
+// set by loaders and parent splitters, is added so that locations stay
+// file-relative. Chunks whose text was rewritten and cannot be found get no
+// location.
+func locateChunks(doc *schema.Document, chunks []*schema.Document) {
+	baseOffset := metaInt(doc.MetaData, MetaStartOffset, 0)
+	baseLine := metaInt(doc.MetaData, MetaStartLine, 1)
+
+	// pos is a byte offset into doc.Content, with its rune offset and line
+	pos, offset, line := 0, 0, 0
+	from := 0
+	for _, chunk := range chunks {
+		i := -1
+		if from <= len(doc.Content) {
+			i = strings.Index(doc.Content[from:], chunk.Content)
+		}
+		if i < 0 || chunk.Content == "" {
+			delete(chunk.MetaData, MetaStartOffset)
+			delete(chunk.MetaData, MetaEndOffset)
+			delete(chunk.MetaData, MetaStartLine)
+			delete(chunk.MetaData, MetaEndLine)
+			continue
+		}
+
+		start := from + i
+		offset += utf8.RuneCountInString(doc.Content[pos:start])
+		line += strings.Count(doc.Content[pos:start], "\n")
+		pos = start
+		// Overlapping chunks start after the previous start, never before it
+		from = start + 1
+
+		chunk.MetaData[MetaStartOffset] = baseOffset + offset
+		chunk.MetaData[MetaEndOffset] = baseOffset + offset + utf8.RuneCountInString(chunk.Content)
+		chunk.MetaData[MetaStartLine] = baseLine + line
+		chunk.MetaData[MetaEndLine] = baseLine + line + strings.Count(chunk.Content, "\n")
+	}
+}
+
+// metaInt reads an integer from metadata, which holds float64 once it has
+// been through JSON
+func metaInt(metadata map[string]any, key string, def int) int {
+	switch v := metadata[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return def
+	}
+}
@@ -0,0 +1,131 @@
+package splitter
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/zibianqu/eino_study/internal/eino/tokenizer"
+	"github.com/zibianqu/eino_study/internal/pkg/markdown"
+)
+
+// RecursiveSplitter splits documents at markdown headings first, then breaks
+// oversized sections at blank lines, lines, sentences, clauses and words, in
+// that order. Chunks never span two sections and carry their heading
+// hierarchy in metadata.
+type RecursiveSplitter struct {
+	TextSplitter
+}
+
+// NewRecursiveSplitter creates a new recursive splitter. A nil tokenizer counts runes.
+func NewRecursiveSplitter(chunkSize, chunkOverlap int, tok tokenizer.Tokenizer) *RecursiveSplitter {
+	return &RecursiveSplitter{TextSplitter: *NewTextSplitter(chunkSize, chunkOverlap, tok)}
+}
+
+// Transform splits a document into chunks, one section at a time
+func (s *RecursiveSplitter) Transform(ctx context.Context, doc *schema.Document) ([]*schema.Document, error) {
+	if doc == nil || len(doc.Content) == 0 {
+		return []*schema.Document{}, nil
+	}
+
+	var result []*schema.Document
+	for _, section := range markdown.Split(doc.Content, 0, parentHeadings(doc)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		chunks := []string{strings.TrimSpace(section.Text)}
+		if s.length(section.Text) > s.ChunkSize {
+			chunks = s.pack(s.splitRecursive(section.Text, 0))
+		}
+
+		for _, chunk := range chunks {
+			// A heading packed on its own adds nothing beyond the heading path
+			if chunk == "" || markdown.IsHeading(chunk) {
+				continue
+			}
+			chunkDoc := s.newChunk(doc, chunk, len(result))
+			if len(section.Headings) > 0 {
+				chunkDoc.MetaData["headings"] = section.Headings
+				chunkDoc.MetaData["heading_path"] = strings.Join(section.Headings, HeadingSeparator)
+			}
+			result = append(result, chunkDoc)
+		}
+	}
+	locateChunks(doc, result)
+
+	return result, nil
+}
+
+// HeadingSeparator joins the headings of a heading path
+const HeadingSeparator = " > "
+
+// separators split text ever more finely; the last level cuts between runes
+var separators = []func(string) []string{
+	splitParagraphs,
+	func(text string) []string { return splitAfter(text, func(r rune) bool { return r == '\n' }) },
+	splitSentences,
+	func(text string) []string { return splitAfter(text, isClauseBreak) },
+	func(text string) []string { return splitAfter(text, unicode.IsSpace) },
+}
+
+// splitRecursive breaks text into pieces that each fit in ChunkSize, using the
+// coarsest separator that works
+func (s *RecursiveSplitter) splitRecursive(text string, level int) []string {
+	if s.length(text) <= s.ChunkSize {
+		return []string{text}
+	}
+	if level >= len(separators) {
+		return s.cutRunes(text)
+	}
+
+	parts := separators[level](text)
+	if len(parts) <= 1 {
+		return s.splitRecursive(text, level+1)
+	}
+
+	var pieces []string
+	for _, part := range parts {
+		pieces = append(pieces, s.splitRecursive(part, level+1)...)
+	}
+	return pieces
+}
+
+// splitParagraphs splits text after each run of blank lines
+func splitParagraphs(text string) []string {
+	var parts []string
+	for {
+		i := strings.Index(text, "\n\n")
+		if i < 0 {
+			break
+		}
+		end := i + 2
+		for end < len(text) && (text[end] == '\n' || text[end] == '\r') {
+			end++
+		}
+		parts = append(parts, text[:end])
+		text = text[end:]
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
+
+// parentHeadings returns the headings above a document that was already cut
+// at a heading, by the markdown loader in section mode or a parent splitter.
+// When the document starts with its own heading, that heading is left to be
+// found in the text.
+func parentHeadings(doc *schema.Document) []string {
+	headings, ok := doc.MetaData["headings"].([]string)
+	if !ok || len(headings) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimLeft(doc.Content, "\n"), "\n")
+	if _, text, consumed := markdown.Heading(lines, 0); consumed > 0 && text == headings[len(headings)-1] {
+		return headings[:len(headings)-1]
+	}
+	return headings
+}
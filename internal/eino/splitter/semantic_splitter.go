@@ -0,0 +1,176 @@
+package splitter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/zibianqu/eino_study/internal/eino/embedding"
+	"github.com/zibianqu/eino_study/internal/eino/tokenizer"
+)
+
+// SemanticSplitter embeds every sentence and starts a new chunk where the
+// distance between neighbouring sentences is unusually large, so that chunks
+// follow topic changes rather than a fixed size
+type SemanticSplitter struct {
+	TextSplitter
+	embedding *embedding.EmbeddingClient
+
+	// Percentile of neighbour distances above which a chunk is cut, e.g. 95
+	Percentile float64
+	// MinChunkSize merges smaller groups into their neighbour; ChunkSize is the maximum
+	MinChunkSize int
+}
+
+// NewSemanticSplitter creates a new semantic splitter. A nil tokenizer counts runes.
+func NewSemanticSplitter(
+	embedding *embedding.EmbeddingClient,
+	percentile float64,
+	minChunkSize, maxChunkSize, chunkOverlap int,
+	tok tokenizer.Tokenizer,
+) *SemanticSplitter {
+	if percentile <= 0 || percentile >= 100 {
+		percentile = 95
+	}
+	base := NewTextSplitter(maxChunkSize, chunkOverlap, tok)
+	if minChunkSize < 0 || minChunkSize > base.ChunkSize {
+		minChunkSize = 0
+	}
+
+	return &SemanticSplitter{
+		TextSplitter: *base,
+		embedding:    embedding,
+		Percentile:   percentile,
+		MinChunkSize: minChunkSize,
+	}
+}
+
+// Transform splits a document at topic changes
+func (s *SemanticSplitter) Transform(ctx context.Context, doc *schema.Document) ([]*schema.Document, error) {
+	if doc == nil || len(doc.Content) == 0 {
+		return []*schema.Document{}, nil
+	}
+
+	var sentences []string
+	for _, sentence := range splitSentences(doc.Content) {
+		if strings.TrimSpace(sentence) == "" && len(sentences) > 0 {
+			sentences[len(sentences)-1] += sentence
+			continue
+		}
+		sentences = append(sentences, s.splitLong(sentence)...)
+	}
+
+	groups := [][]string{sentences}
+	if len(sentences) > 2 {
+		breaks, err := s.breakpoints(ctx, sentences)
+		if err != nil {
+			return nil, err
+		}
+		groups = s.group(sentences, breaks)
+	}
+
+	var result []*schema.Document
+	for _, group := range groups {
+		chunks := []string{strings.TrimSpace(strings.Join(group, ""))}
+		if s.length(chunks[0]) > s.ChunkSize {
+			chunks = s.pack(group)
+		}
+		for _, chunk := range chunks {
+			if chunk == "" {
+				continue
+			}
+			result = append(result, s.newChunk(doc, chunk, len(result)))
+		}
+	}
+	locateChunks(doc, result)
+
+	return result, nil
+}
+
+// breakpoints returns the indexes of sentences that start a new topic
+func (s *SemanticSplitter) breakpoints(ctx context.Context, sentences []string) ([]bool, error) {
+	texts := make([]string, len(sentences))
+	for i, sentence := range sentences {
+		texts[i] = strings.TrimSpace(sentence)
+		if texts[i] == "" {
+			texts[i] = " "
+		}
+	}
+
+	vectors, err := s.embedding.EmbedTexts(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed sentences: %w", err)
+	}
+	if len(vectors) != len(sentences) {
+		return nil, fmt.Errorf("embedded %d of %d sentences", len(vectors), len(sentences))
+	}
+
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosineSimilarity(vectors[i], vectors[i+1])
+	}
+	threshold := percentile(distances, s.Percentile)
+
+	breaks := make([]bool, len(sentences))
+	for i, d := range distances {
+		if d > threshold {
+			breaks[i+1] = true
+		}
+	}
+	return breaks, nil
+}
+
+// group cuts sentences at the breakpoints, merging groups below MinChunkSize
+// into the following group
+func (s *SemanticSplitter) group(sentences []string, breaks []bool) [][]string {
+	var groups [][]string
+	var current []string
+	size := 0
+	for i, sentence := range sentences {
+		if breaks[i] && len(current) > 0 && size >= s.MinChunkSize {
+			groups = append(groups, current)
+			current, size = nil, 0
+		}
+		current = append(current, sentence)
+		size += s.length(sentence)
+	}
+	if len(current) > 0 {
+		// A short tail joins the group before it
+		if size < s.MinChunkSize && len(groups) > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], current...)
+		} else {
+			groups = append(groups, current)
+		}
+	}
+	return groups
+}
+
+// percentile returns the p-th percentile of values using linear interpolation
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
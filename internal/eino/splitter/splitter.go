@@ -0,0 +1,65 @@
+package splitter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/zibianqu/eino_study/internal/config"
+	"github.com/zibianqu/eino_study/internal/eino/embedding"
+	"github.com/zibianqu/eino_study/internal/eino/tokenizer"
+)
+
+// Splitter splits a document into chunks
+type Splitter interface {
+	Transform(ctx context.Context, doc *schema.Document) ([]*schema.Document, error)
+}
+
+// Splitter types
+const (
+	TypeText      = "text"
+	TypeRecursive = "recursive"
+	TypeSemantic  = "semantic"
+)
+
+// NewSplitter creates the splitter selected by cfg.Type. The embedding client
+// is only used by the semantic splitter.
+func NewSplitter(cfg *config.SplitterConfig, tok tokenizer.Tokenizer, embeddingClient *embedding.EmbeddingClient) (Splitter, error) {
+	switch cfg.Type {
+	case "", TypeText:
+		return NewTextSplitter(cfg.ChunkSize, cfg.ChunkOverlap, tok), nil
+	case TypeRecursive:
+		return NewRecursiveSplitter(cfg.ChunkSize, cfg.ChunkOverlap, tok), nil
+	case TypeSemantic:
+		if embeddingClient == nil {
+			return nil, fmt.Errorf("semantic splitter requires an embedding client")
+		}
+		return NewSemanticSplitter(
+			embeddingClient,
+			cfg.BreakpointPercentile,
+			cfg.MinChunkSize,
+			cfg.ChunkSize,
+			cfg.ChunkOverlap,
+			tok,
+		), nil
+	default:
+		return nil, fmt.Errorf("unsupported splitter type: %s", cfg.Type)
+	}
+}
+
+// NewParentSplitter creates the splitter that cuts documents into parent
+// chunks of cfg.ParentChunkSize, or returns nil when parent-child chunking is off
+func NewParentSplitter(cfg *config.SplitterConfig, tok tokenizer.Tokenizer) (Splitter, error) {
+	if cfg.ParentChunkSize <= 0 {
+		return nil, nil
+	}
+	if cfg.ParentChunkSize <= cfg.ChunkSize {
+		return nil, fmt.Errorf("parent_chunk_size %d must be larger than chunk_size %d", cfg.ParentChunkSize, cfg.ChunkSize)
+	}
+
+	// Parents are not embedded, so semantic splitting is left to the children
+	if cfg.Type == TypeRecursive || cfg.Type == TypeSemantic {
+		return NewRecursiveSplitter(cfg.ParentChunkSize, 0, tok), nil
+	}
+	return NewTextSplitter(cfg.ParentChunkSize, 0, tok), nil
+}
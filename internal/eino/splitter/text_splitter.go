@@ -3,18 +3,24 @@ package splitter
 import (
 	"context"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/zibianqu/eino_study/internal/eino/tokenizer"
 )
 
-// TextSplitter splits documents into chunks
+// TextSplitter splits documents into chunks. Sizes are measured by the
+// tokenizer, in runes by default, and chunks break at sentence boundaries,
+// including CJK punctuation.
 type TextSplitter struct {
 	ChunkSize    int
 	ChunkOverlap int
+	Tokenizer    tokenizer.Tokenizer
 }
 
-// NewTextSplitter creates a new text splitter
-func NewTextSplitter(chunkSize, chunkOverlap int) *TextSplitter {
+// NewTextSplitter creates a new text splitter. A nil tokenizer counts runes.
+func NewTextSplitter(chunkSize, chunkOverlap int, tok tokenizer.Tokenizer) *TextSplitter {
 	if chunkSize <= 0 {
 		chunkSize = 1000
 	}
@@ -25,9 +31,14 @@ func NewTextSplitter(chunkSize, chunkOverlap int) *TextSplitter {
 		chunkOverlap = chunkSize / 4
 	}
 
+	if tok == nil {
+		tok, _ = tokenizer.New(tokenizer.Runes, "")
+	}
+
 	return &TextSplitter{
 		ChunkSize:    chunkSize,
 		ChunkOverlap: chunkOverlap,
+		Tokenizer:    tok,
 	}
 }
 
@@ -40,75 +51,240 @@ func (s *TextSplitter) Transform(ctx context.Context, doc *schema.Document) ([]*
 	chunks := s.splitText(doc.Content)
 	result := make([]*schema.Document, 0, len(chunks))
 
-	for i, chunk := range chunks {
+	for _, chunk := range chunks {
 		if len(strings.TrimSpace(chunk)) == 0 {
 			continue
 		}
+		result = append(result, s.newChunk(doc, chunk, len(result)))
+	}
+	locateChunks(doc, result)
 
-		// Create metadata for chunk
-		metadata := make(map[string]any)
-		for k, v := range doc.MetaData {
-			metadata[k] = v
-		}
-		metadata["chunk_index"] = i
-		metadata["chunk_size"] = len(chunk)
+	return result, nil
+}
 
-		result = append(result, &schema.Document{
-			Content:  chunk,
-			MetaData: metadata,
-		})
+// newChunk creates a chunk document carrying the metadata of its source document
+func (s *TextSplitter) newChunk(doc *schema.Document, chunk string, index int) *schema.Document {
+	metadata := make(map[string]any, len(doc.MetaData)+3)
+	for k, v := range doc.MetaData {
+		metadata[k] = v
+	}
+	metadata["chunk_index"] = index
+	metadata["chunk_size"] = utf8.RuneCountInString(chunk)
+	if s.Tokenizer.Name() != tokenizer.Runes {
+		metadata["token_count"] = s.length(chunk)
 	}
 
-	return result, nil
+	return &schema.Document{
+		Content:  chunk,
+		MetaData: metadata,
+	}
 }
 
-// splitText splits text into chunks with overlap
+// length measures text in the splitter's unit
+func (s *TextSplitter) length(text string) int {
+	return s.Tokenizer.Count(text)
+}
+
+// splitText packs whole sentences into chunks of at most ChunkSize
 func (s *TextSplitter) splitText(text string) []string {
-	if len(text) <= s.ChunkSize {
-		return []string{text}
+	if s.length(text) <= s.ChunkSize {
+		return []string{strings.TrimSpace(text)}
+	}
+
+	var pieces []string
+	for _, sentence := range splitSentences(text) {
+		pieces = append(pieces, s.splitLong(sentence)...)
+	}
+	return s.pack(pieces)
+}
+
+// pack joins consecutive pieces into chunks of at most ChunkSize. Each chunk
+// after the first starts with the trailing pieces of the previous chunk that
+// fit in ChunkOverlap.
+func (s *TextSplitter) pack(pieces []string) []string {
+	sizes := make([]int, len(pieces))
+	for i, piece := range pieces {
+		sizes[i] = s.length(piece)
 	}
 
 	var chunks []string
 	start := 0
-
-	for start < len(text) {
-		end := start + s.ChunkSize
-		if end > len(text) {
-			end = len(text)
+	for start < len(pieces) {
+		end, size := start, 0
+		for end < len(pieces) && (end == start || size+sizes[end] <= s.ChunkSize) {
+			size += sizes[end]
+			end++
 		}
 
-		// Try to break at sentence or word boundary
-		if end < len(text) {
-			// Look for sentence ending
-			for i := end; i > start && i > end-100; i-- {
-				if text[i] == '.' || text[i] == '!' || text[i] == '?' || text[i] == '\n' {
-					end = i + 1
+		// Prefer ending at a paragraph break when one falls in the second half
+		if end < len(pieces) {
+			for k, kept := end-1, size; k > start && kept > s.ChunkSize/2; k-- {
+				kept -= sizes[k]
+				if strings.Contains(pieces[k-1], "\n\n") && kept > s.ChunkSize/2 {
+					end = k
 					break
 				}
 			}
+		}
 
-			// If no sentence boundary, look for space
-			if end == start+s.ChunkSize {
-				for i := end; i > start && i > end-50; i-- {
-					if text[i] == ' ' {
-						end = i
-						break
-					}
-				}
+		if chunk := strings.TrimSpace(strings.Join(pieces[start:end], "")); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end >= len(pieces) {
+			break
+		}
+
+		// Step back over whole pieces for the overlap, always moving forward
+		// and leaving room in the next chunk for at least one new piece
+		next, overlap := end, 0
+		for next-1 > start && overlap+sizes[next-1] <= s.ChunkOverlap &&
+			overlap+sizes[next-1]+sizes[end] <= s.ChunkSize {
+			next--
+			overlap += sizes[next]
+		}
+		start = next
+	}
+
+	return chunks
+}
+
+// splitLong breaks a sentence longer than ChunkSize at clause and word
+// boundaries, cutting between runes only when no boundary is available
+func (s *TextSplitter) splitLong(sentence string) []string {
+	if s.length(sentence) <= s.ChunkSize {
+		return []string{sentence}
+	}
+
+	var pieces []string
+	for _, part := range splitAfter(sentence, isClauseBreak) {
+		if s.length(part) <= s.ChunkSize {
+			pieces = append(pieces, part)
+			continue
+		}
+		for _, word := range splitAfter(part, unicode.IsSpace) {
+			if s.length(word) <= s.ChunkSize {
+				pieces = append(pieces, word)
+				continue
 			}
+			pieces = append(pieces, s.cutRunes(word)...)
 		}
+	}
+	return mergeSmall(pieces, s.ChunkSize, s.length)
+}
 
-		chunks = append(chunks, text[start:end])
+// cutRunes cuts text into pieces of at most ChunkSize, never inside a rune
+func (s *TextSplitter) cutRunes(text string) []string {
+	var pieces []string
+	runes := []rune(text)
+	for len(runes) > 0 {
+		// Find the longest prefix that fits; at least one rune is always taken
+		lo, hi := 1, min(s.ChunkSize, len(runes))
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if s.length(string(runes[:mid])) <= s.ChunkSize {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		pieces = append(pieces, string(runes[:lo]))
+		runes = runes[lo:]
+	}
+	return pieces
+}
 
-		// Move start position with overlap
-		if end >= len(text) {
-			break
+// mergeSmall joins neighbouring pieces while they fit in size, so that word
+// splitting does not leave one piece per word
+func mergeSmall(pieces []string, size int, length func(string) int) []string {
+	var merged []string
+	current, currentSize := "", 0
+	for _, piece := range pieces {
+		n := length(piece)
+		if current != "" && currentSize+n > size {
+			merged = append(merged, current)
+			current, currentSize = "", 0
 		}
-		start = end - s.ChunkOverlap
-		if start < 0 {
-			start = 0
+		current += piece
+		currentSize += n
+	}
+	if current != "" {
+		merged = append(merged, current)
+	}
+	return merged
+}
+
+// sentenceEnders end a sentence in Latin or CJK text
+const sentenceEnders = ".!?;。！？；…"
+
+// closingPunct may follow a sentence ender and stays with its sentence
+const closingPunct = "\"')]}”’」』）】》"
+
+// splitSentences splits text into sentences and paragraph fragments. Every
+// sentence keeps its terminator and trailing whitespace, so joining the
+// result reproduces text exactly.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+
+		switch {
+		case r == '\n':
+		case strings.ContainsRune(sentenceEnders, r):
+			// Runs such as "?!" or "……" end a sentence together
+			for i < len(text) {
+				next, n := utf8.DecodeRuneInString(text[i:])
+				if !strings.ContainsRune(sentenceEnders, next) && !strings.ContainsRune(closingPunct, next) {
+					break
+				}
+				i += n
+			}
+			// An ASCII ender needs whitespace after it, so "3.14" and "a.b" stay whole
+			if r < utf8.RuneSelf && i < len(text) {
+				if next, _ := utf8.DecodeRuneInString(text[i:]); !unicode.IsSpace(next) {
+					continue
+				}
+			}
+		default:
+			continue
+		}
+
+		// Trailing whitespace, including blank lines, belongs to the sentence
+		for i < len(text) {
+			next, n := utf8.DecodeRuneInString(text[i:])
+			if !unicode.IsSpace(next) {
+				break
+			}
+			i += n
 		}
+		sentences = append(sentences, text[start:i])
+		start = i
 	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
 
-	return chunks
-}
\ No newline at end of file
+// isClauseBreak reports punctuation that separates clauses within a sentence
+func isClauseBreak(r rune) bool {
+	return strings.ContainsRune(",:，、：", r)
+}
+
+// splitAfter splits text after every rune matching sep, keeping the separator
+func splitAfter(text string, sep func(rune) bool) []string {
+	var parts []string
+	start := 0
+	for i, r := range text {
+		if sep(r) {
+			end := i + utf8.RuneLen(r)
+			parts = append(parts, text[start:end])
+			start = end
+		}
+	}
+	if start < len(text) {
+		parts = append(parts, text[start:])
+	}
+	return parts
+}
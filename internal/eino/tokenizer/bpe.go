@@ -0,0 +1,196 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// bpe is a byte level BPE tokenizer compatible with tiktoken encodings
+type bpe struct {
+	name    string
+	ranks   map[string]int
+	decoder map[int][]byte
+	split   func(text string) []string
+
+	// Words repeat a lot in a corpus, so encoded pieces are memoised
+	mu    sync.RWMutex
+	cache map[string][]int
+}
+
+// maxCachedPieces bounds the piece cache of a tokenizer
+const maxCachedPieces = 1 << 16
+
+func newBPE(name string, ranks map[string]int, split func(string) []string) *bpe {
+	decoder := make(map[int][]byte, len(ranks))
+	for piece, rank := range ranks {
+		decoder[rank] = []byte(piece)
+	}
+	return &bpe{
+		name:    name,
+		ranks:   ranks,
+		decoder: decoder,
+		split:   split,
+		cache:   make(map[string][]int),
+	}
+}
+
+// Name returns the encoding name
+func (t *bpe) Name() string {
+	return t.name
+}
+
+// Encode splits text into token ids. Special tokens are encoded as ordinary text.
+func (t *bpe) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range t.split(text) {
+		tokens = append(tokens, t.encodePiece(piece)...)
+	}
+	return tokens
+}
+
+// Decode joins token ids back into text
+func (t *bpe) Decode(tokens []int) string {
+	var buf bytes.Buffer
+	for _, token := range tokens {
+		buf.Write(t.decoder[token])
+	}
+	return buf.String()
+}
+
+// Count returns the number of tokens in text
+func (t *bpe) Count(text string) int {
+	n := 0
+	for _, piece := range t.split(text) {
+		n += len(t.encodePiece(piece))
+	}
+	return n
+}
+
+func (t *bpe) encodePiece(piece string) []int {
+	if rank, ok := t.ranks[piece]; ok {
+		return []int{rank}
+	}
+
+	t.mu.RLock()
+	tokens, ok := t.cache[piece]
+	t.mu.RUnlock()
+	if ok {
+		return tokens
+	}
+
+	tokens = t.merge([]byte(piece))
+
+	t.mu.Lock()
+	if len(t.cache) >= maxCachedPieces {
+		clear(t.cache)
+	}
+	t.cache[piece] = tokens
+	t.mu.Unlock()
+	return tokens
+}
+
+// merge applies BPE merges to a piece, always merging the adjacent pair with
+// the lowest rank first, as tiktoken does
+func (t *bpe) merge(piece []byte) []int {
+	// boundaries[i] is the start of the i-th part; the last entry is len(piece)
+	boundaries := make([]int, len(piece)+1)
+	for i := range boundaries {
+		boundaries[i] = i
+	}
+
+	pairRank := func(i int) int {
+		if i+2 >= len(boundaries) {
+			return math.MaxInt
+		}
+		if rank, ok := t.ranks[string(piece[boundaries[i]:boundaries[i+2]])]; ok {
+			return rank
+		}
+		return math.MaxInt
+	}
+
+	ranks := make([]int, len(boundaries)-1)
+	for i := range ranks {
+		ranks[i] = pairRank(i)
+	}
+
+	for len(boundaries) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i, rank := range ranks[:len(ranks)-1] {
+			if rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		boundaries = append(boundaries[:best+1], boundaries[best+2:]...)
+		ranks = append(ranks[:best+1], ranks[best+2:]...)
+		ranks[best] = pairRank(best)
+		if best > 0 {
+			ranks[best-1] = pairRank(best - 1)
+		}
+	}
+
+	tokens := make([]int, 0, len(boundaries)-1)
+	for i := 0; i+1 < len(boundaries); i++ {
+		// Every part is at least a single byte, and readRanks checks all bytes are ranked
+		tokens = append(tokens, t.ranks[string(piece[boundaries[i]:boundaries[i+1]])])
+	}
+	return tokens
+}
+
+// loadRanks reads a tiktoken vocabulary file
+func loadRanks(path string) (map[string]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readRanks(file, path)
+}
+
+// readRanks parses a tiktoken vocabulary: one base64 encoded token and its
+// rank per line
+func readRanks(r io.Reader, name string) (map[string]int, error) {
+	ranks := make(map[string]int, 100000)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		encoded, rankText, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"<base64 token> <rank>\"", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(rankText)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("vocabulary %s is missing byte %#02x", name, b)
+		}
+	}
+	return ranks, nil
+}
@@ -0,0 +1,159 @@
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// The tiktoken split patterns rely on lookahead, which Go's regexp does not
+// support, so they are implemented here as hand written scanners.
+
+// splitCL100K splits text like the cl100k_base pattern:
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitCL100K(text string) []string {
+	return scan(text, func(s []rune) int {
+		if n := contraction(s, true); n > 0 {
+			return n
+		}
+		if isLetter(s[0]) {
+			return 1 + countWhile(s[1:], isLetter)
+		}
+		if len(s) > 1 && !isNewline(s[0]) && !isNumber(s[0]) && isLetter(s[1]) {
+			return 2 + countWhile(s[2:], isLetter)
+		}
+		if isNumber(s[0]) {
+			return min(3, countWhile(s, isNumber))
+		}
+		if n := punctuation(s); n > 0 {
+			return n + countWhile(s[n:], isNewline)
+		}
+
+		ws := countWhile(s, unicode.IsSpace)
+		for i := ws - 1; i >= 0; i-- {
+			if isNewline(s[i]) {
+				return i + 1
+			}
+		}
+		return trailingSpace(s, ws)
+	})
+}
+
+// splitGPT2 splits text like the r50k_base and p50k_base pattern:
+//
+//	's|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+
+func splitGPT2(text string) []string {
+	return scan(text, func(s []rune) int {
+		if n := contraction(s, false); n > 0 {
+			return n
+		}
+		offset := 0
+		if s[0] == ' ' && len(s) > 1 {
+			offset = 1
+		}
+		if isLetter(s[offset]) {
+			return offset + countWhile(s[offset:], isLetter)
+		}
+		if isNumber(s[offset]) {
+			return offset + countWhile(s[offset:], isNumber)
+		}
+		if n := punctuation(s); n > 0 {
+			return n
+		}
+		return trailingSpace(s, countWhile(s, unicode.IsSpace))
+	})
+}
+
+// scan splits text into pieces, next returning the length in runes of the piece at the start of s
+func scan(text string, next func(s []rune) int) []string {
+	runes := []rune(text)
+	pieces := make([]string, 0, len(runes)/3+1)
+
+	// Pieces are sliced from text so that invalid UTF-8 round trips byte for byte
+	offset := 0
+	for len(runes) > 0 {
+		n := max(1, next(runes))
+		size := 0
+		for i := 0; i < n; i++ {
+			_, width := utf8.DecodeRuneInString(text[offset+size:])
+			size += width
+		}
+		pieces = append(pieces, text[offset:offset+size])
+		offset += size
+		runes = runes[n:]
+	}
+	return pieces
+}
+
+// contraction matches 's, 't, 're, 've, 'm, 'll and 'd
+func contraction(s []rune, foldCase bool) int {
+	if len(s) < 2 || s[0] != '\'' {
+		return 0
+	}
+	lower := func(r rune) rune {
+		if foldCase {
+			return unicode.ToLower(r)
+		}
+		return r
+	}
+	switch lower(s[1]) {
+	case 's', 't', 'm', 'd':
+		return 2
+	}
+	if len(s) > 2 {
+		switch string([]rune{lower(s[1]), lower(s[2])}) {
+		case "re", "ve", "ll":
+			return 3
+		}
+	}
+	return 0
+}
+
+// punctuation matches ` ?[^\s\p{L}\p{N}]+`
+func punctuation(s []rune) int {
+	offset := 0
+	if s[0] == ' ' && len(s) > 1 {
+		offset = 1
+	}
+	n := countWhile(s[offset:], isPunct)
+	if n == 0 {
+		return 0
+	}
+	return offset + n
+}
+
+// trailingSpace matches `\s+(?!\S)|\s+` given the length of the whitespace run:
+// a run followed by text leaves its last space to prefix the next word
+func trailingSpace(s []rune, ws int) int {
+	if ws == 0 {
+		return 1
+	}
+	if ws < len(s) && ws > 1 {
+		return ws - 1
+	}
+	return ws
+}
+
+func countWhile(s []rune, match func(rune) bool) int {
+	n := 0
+	for n < len(s) && match(s[n]) {
+		n++
+	}
+	return n
+}
+
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r)
+}
+
+func isNumber(r rune) bool {
+	return unicode.IsNumber(r)
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+func isPunct(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
@@ -0,0 +1,106 @@
+package tokenizer
+
+import (
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// Tokenizer measures text the way a model does
+type Tokenizer interface {
+	// Name returns the encoding name, e.g. cl100k_base
+	Name() string
+	// Encode splits text into token ids
+	Encode(text string) []int
+	// Decode joins token ids back into text
+	Decode(tokens []int) string
+	// Count returns the number of tokens in text
+	Count(text string) int
+}
+
+// Runes is the encoding name of the rune counting tokenizer
+const Runes = "runes"
+
+// encoding is a BPE encoding: its pre-tokenizer and the embedded vocabulary
+// holding its ranks
+type encoding struct {
+	split func(text string) []string
+	vocab string
+}
+
+// encodings maps an encoding name to its definition
+var encodings = map[string]encoding{
+	"cl100k_base": {split: splitCL100K, vocab: "cl100k_base"},
+	"p50k_base":   {split: splitGPT2, vocab: "p50k_base"},
+	"p50k_edit":   {split: splitGPT2, vocab: "p50k_base"},
+	"r50k_base":   {split: splitGPT2, vocab: "r50k_base"},
+	"gpt2":        {split: splitGPT2, vocab: "r50k_base"},
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]Tokenizer{}
+)
+
+// New returns the tokenizer for an encoding. BPE encodings read their ranks
+// from the tiktoken file vocabFile if given, or else from the vocabulary
+// compiled into the binary, which is only there when make tokenizers was run
+// before building. An empty encoding or "runes" counts runes instead of tokens.
+func New(name, vocabFile string) (Tokenizer, error) {
+	if name == "" || name == Runes {
+		return runeTokenizer{}, nil
+	}
+
+	enc, ok := encodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported tokenizer encoding: %s", name)
+	}
+
+	// Vocabularies are large, so each one is loaded once per process
+	key := name + "\x00" + vocabFile
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if t, ok := cache[key]; ok {
+		return t, nil
+	}
+
+	var ranks map[string]int
+	var err error
+	if vocabFile != "" {
+		ranks, err = loadRanks(vocabFile)
+	} else {
+		ranks, err = embeddedRanks(enc.vocab)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s vocabulary: %w", name, err)
+	}
+
+	t := newBPE(name, ranks, enc.split)
+	cache[key] = t
+	return t, nil
+}
+
+// runeTokenizer treats every rune as a token
+type runeTokenizer struct{}
+
+func (runeTokenizer) Name() string { return Runes }
+
+func (runeTokenizer) Encode(text string) []int {
+	tokens := make([]int, 0, utf8.RuneCountInString(text))
+	for _, r := range text {
+		tokens = append(tokens, int(r))
+	}
+	return tokens
+}
+
+func (runeTokenizer) Decode(tokens []int) string {
+	runes := make([]rune, len(tokens))
+	for i, t := range tokens {
+		runes[i] = rune(t)
+	}
+	return string(runes)
+}
+
+func (runeTokenizer) Count(text string) int {
+	return utf8.RuneCountInString(text)
+}
@@ -0,0 +1,25 @@
+package tokenizer
+
+import (
+	"embed"
+	"fmt"
+	"path"
+)
+
+// vocabFS holds the tiktoken vocabularies found in vocab/ at build time.
+// They are not part of the source tree; make tokenizers downloads them before
+// building, see vocab/README.md.
+//
+//go:embed vocab
+var vocabFS embed.FS
+
+// embeddedRanks reads the ranks of an embedded vocabulary
+func embeddedRanks(vocab string) (map[string]int, error) {
+	file, err := vocabFS.Open(path.Join("vocab", vocab+".tiktoken"))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not embedded in this build, run make tokenizers or set vocab_file: %w", vocab, err)
+	}
+	defer file.Close()
+
+	return readRanks(file, vocab)
+}
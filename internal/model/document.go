@@ -21,15 +21,25 @@ func (Document) TableName() string {
 	return "documents"
 }
 
+// Chunk types
+const (
+	ChunkTypeChunk  = "chunk"  // standalone chunk with an embedding
+	ChunkTypeParent = "parent" // large chunk returned to the LLM, without an embedding
+	ChunkTypeChild  = "child"  // small chunk with an embedding, searched in place of its parent
+)
+
 // DocumentChunk represents the document_chunks table
 type DocumentChunk struct {
-	ID         int       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	DocID      string    `gorm:"column:doc_id;type:varchar(32);not null" json:"doc_id"`
-	ChunkIndex int       `gorm:"column:chunk_index;not null" json:"chunk_index"`
-	Content    string    `gorm:"column:content;type:text;not null" json:"content"`
-	Embedding  string    `gorm:"column:embedding;type:vector(1536)" json:"-"`
-	Metadata   string    `gorm:"column:metadata;type:jsonb" json:"metadata"`
-	CTime      time.Time `gorm:"column:ctime;default:CURRENT_TIMESTAMP" json:"ctime"`
+	ID             int       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	DocID          string    `gorm:"column:doc_id;type:varchar(32);not null" json:"doc_id"`
+	ChunkIndex     int       `gorm:"column:chunk_index;not null" json:"chunk_index"`
+	ChunkType      string    `gorm:"column:chunk_type;type:varchar(10);not null;default:chunk" json:"chunk_type"`
+	ParentID       *int      `gorm:"column:parent_id" json:"parent_id,omitempty"`
+	Content        string    `gorm:"column:content;type:text;not null" json:"content"`
+	Embedding      *string   `gorm:"column:embedding;type:vector" json:"-"`                                     // Dimension follows the embedding model, checked at startup
+	EmbeddingModel string    `gorm:"column:embedding_model;type:varchar(255)" json:"embedding_model,omitempty"` // provider/model/dimension that produced Embedding
+	Metadata       string    `gorm:"column:metadata;type:jsonb" json:"metadata"`
+	CTime          time.Time `gorm:"column:ctime;default:CURRENT_TIMESTAMP" json:"ctime"`
 }
 
 // TableName specifies the table name
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// EmbeddingCache represents the embedding_cache table. Vectors are keyed by
+// the embedding model and the SHA-256 of the embedded text, so unchanged text
+// is never sent to the provider twice.
+type EmbeddingCache struct {
+	Model       string    `gorm:"column:model;primaryKey;type:varchar(255)" json:"model"`
+	ContentHash string    `gorm:"column:content_hash;primaryKey;type:char(64)" json:"content_hash"`
+	Embedding   string    `gorm:"column:embedding;type:vector;not null" json:"-"`
+	CTime       time.Time `gorm:"column:ctime;default:CURRENT_TIMESTAMP" json:"ctime"`
+}
+
+// TableName specifies the table name
+func (EmbeddingCache) TableName() string {
+	return "embedding_cache"
+}
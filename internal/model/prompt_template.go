@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// PromptTemplate represents the prompt_templates table. Each row is one
+// version of a named RAG prompt; new versions are added, not edited in place.
+type PromptTemplate struct {
+	PromptID string    `gorm:"column:prompt_id;primaryKey;type:varchar(100)" json:"prompt_id"`
+	Version  int       `gorm:"column:version;primaryKey" json:"version"`
+	Format   string    `gorm:"column:format;type:varchar(20);not null;default:fstring" json:"format"`
+	System   string    `gorm:"column:system_template;type:text" json:"system"`
+	User     string    `gorm:"column:user_template;type:text;not null" json:"user"`
+	Document string    `gorm:"column:document_template;type:text" json:"document"`
+	NoAnswer string    `gorm:"column:no_answer;type:text" json:"no_answer"`
+	CTime    time.Time `gorm:"column:ctime;default:CURRENT_TIMESTAMP" json:"ctime"`
+}
+
+// TableName specifies the table name
+func (PromptTemplate) TableName() string {
+	return "prompt_templates"
+}
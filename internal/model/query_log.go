@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// QueryLog represents the query_log table: one row per answered query with
+// the token usage reported by the LLM and its estimated cost
+type QueryLog struct {
+	ID               int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	APIKey           string    `gorm:"column:api_key;type:varchar(64);not null;default:''" json:"api_key"`
+	Query            string    `gorm:"column:query;type:text;not null" json:"query"`
+	Backend          string    `gorm:"column:backend;type:varchar(255);not null;default:''" json:"backend"`
+	Model            string    `gorm:"column:model;type:varchar(255);not null;default:''" json:"model"`
+	PromptTokens     int       `gorm:"column:prompt_tokens;not null;default:0" json:"prompt_tokens"`
+	CompletionTokens int       `gorm:"column:completion_tokens;not null;default:0" json:"completion_tokens"`
+	TotalTokens      int       `gorm:"column:total_tokens;not null;default:0" json:"total_tokens"`
+	LatencyMs        int64     `gorm:"column:latency_ms;not null;default:0" json:"latency_ms"`
+	Cost             float64   `gorm:"column:cost;type:numeric(14,6);not null;default:0" json:"cost"`
+	CTime            time.Time `gorm:"column:ctime;default:CURRENT_TIMESTAMP" json:"ctime"`
+}
+
+// TableName specifies the table name
+func (QueryLog) TableName() string {
+	return "query_log"
+}
@@ -0,0 +1,119 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Section is the text under one heading, including the heading lines
+type Section struct {
+	Headings []string // Heading path from the top level down to this section
+	Level    int      // Level of the section's own heading, 0 above the first heading
+	Text     string
+}
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextHeading = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	codeFence     = regexp.MustCompile("^ {0,3}(```|~~~)")
+)
+
+// Heading reports the heading on lines[i], if any, and how many lines it
+// takes. Setext headings are recognised by the underline on the following line.
+func Heading(lines []string, i int) (level int, text string, consumed int) {
+	if m := atxHeading.FindStringSubmatch(lines[i]); m != nil {
+		return len(m[1]), strings.TrimSpace(m[2]), 1
+	}
+	if i+1 < len(lines) && strings.TrimSpace(lines[i]) != "" && !strings.HasPrefix(strings.TrimSpace(lines[i]), "- ") {
+		if m := setextHeading.FindStringSubmatch(lines[i+1]); m != nil {
+			level = 2
+			if m[1][0] == '=' {
+				level = 1
+			}
+			return level, strings.TrimSpace(lines[i]), 2
+		}
+	}
+	return 0, "", 0
+}
+
+// IsHeading reports whether text consists of a single heading
+func IsHeading(text string) bool {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	_, _, consumed := Heading(lines, 0)
+	return consumed > 0 && consumed == len(lines)
+}
+
+// Split splits markdown at headings up to maxLevel, or at all headings if
+// maxLevel <= 0, ignoring headings inside fenced code blocks. Parents are the
+// headings above the text, which stay above every heading found in it.
+// Sections holding nothing but their heading are dropped, since their heading
+// is part of the path of the sections below.
+func Split(text string, maxLevel int, parents []string) []Section {
+	if maxLevel <= 0 {
+		maxLevel = 6
+	}
+	lines := strings.Split(text, "\n")
+
+	type heading struct {
+		level int
+		text  string
+	}
+	stack := make([]heading, 0, len(parents)+6)
+	for _, p := range parents {
+		stack = append(stack, heading{level: 0, text: p})
+	}
+
+	var (
+		sections []Section
+		current  []string
+		hasBody  bool
+		fence    string
+	)
+	flush := func() {
+		if hasBody {
+			section := Section{Text: strings.Join(current, "\n")}
+			for _, h := range stack {
+				section.Headings = append(section.Headings, h.text)
+			}
+			if len(stack) > 0 {
+				section.Level = stack[len(stack)-1].level
+			}
+			sections = append(sections, section)
+		}
+		current, hasBody = nil, false
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := codeFence.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case fence == m[1]:
+				fence = ""
+			}
+		}
+
+		if fence == "" {
+			if level, text, consumed := Heading(lines, i); consumed > 0 && level <= maxLevel {
+				flush()
+				for len(stack) > 0 && stack[len(stack)-1].level >= level {
+					stack = stack[:len(stack)-1]
+				}
+				stack = append(stack, heading{level: level, text: text})
+				current = append(current, lines[i:i+consumed]...)
+				i += consumed - 1
+				continue
+			}
+		}
+
+		current = append(current, line)
+		if strings.TrimSpace(line) != "" {
+			hasBody = true
+		}
+	}
+	flush()
+
+	return sections
+}
@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 // Response represents a standard API response
 type Response struct {
 	Code    int         `json:"code"`
@@ -25,35 +27,150 @@ type DocumentUploadRequest struct {
 
 // QueryRequest represents a query request
 type QueryRequest struct {
-	Query   string `json:"query" binding:"required"`
-	TopK    int    `json:"top_k,omitempty"`
-	Stream  bool   `json:"stream,omitempty"`
+	Query  string `json:"query" binding:"required"`
+	Stream bool   `json:"stream,omitempty"`
+
+	// Retrieval parameters, the configured ones if unset
+	TopK                int           `json:"top_k,omitempty" binding:"min=0,max=100"`
+	SimilarityThreshold *float64      `json:"similarity_threshold,omitempty" binding:"omitempty,min=-1,max=1"`
+	Filters             *QueryFilters `json:"filters,omitempty"`
+
+	// Prompt template, the configured default if empty, at its latest
+	// version unless prompt_version is set
+	PromptID      string `json:"prompt_id,omitempty"`
+	PromptVersion int    `json:"prompt_version,omitempty"`
+}
+
+// QueryFilters restricts the chunks a query is answered from
+type QueryFilters struct {
+	DocIDs    []string       `json:"doc_ids,omitempty"`
+	FileType  string         `json:"file_type,omitempty"`  // Document extension, e.g. "pdf" or ".pdf"
+	Metadata  map[string]any `json:"metadata,omitempty"`   // Chunk metadata keys and the values they must have
+	CTimeFrom *time.Time     `json:"ctime_from,omitempty"` // Documents created at or after, RFC 3339
+	CTimeTo   *time.Time     `json:"ctime_to,omitempty"`   // Documents created before, RFC 3339
 }
 
 // QueryResponse represents a query response
 type QueryResponse struct {
-	Answer      string              `json:"answer"`
-	Sources     []SourceInfo        `json:"sources,omitempty"`
-	Usage       *UsageInfo          `json:"usage,omitempty"`
+	Answer        string       `json:"answer"`
+	Sources       []SourceInfo `json:"sources,omitempty"`
+	Usage         *UsageInfo   `json:"usage,omitempty"`
+	Backend       string       `json:"backend,omitempty"` // LLM backend that generated the answer
+	PromptID      string       `json:"prompt_id,omitempty"`
+	PromptVersion int          `json:"prompt_version,omitempty"`
+}
+
+// Events of a streamed query, sent as server-sent events in this order:
+// one sources event, delta events with pieces of the answer, and one usage
+// event. An error event replaces the rest when the answer fails midway.
+const (
+	QueryEventSources = "sources"
+	QueryEventDelta   = "delta"
+	QueryEventUsage   = "usage"
+	QueryEventError   = "error"
+)
+
+// QuerySourcesEvent is the first event of a streamed query
+type QuerySourcesEvent struct {
+	Sources       []SourceInfo `json:"sources"`
+	PromptID      string       `json:"prompt_id,omitempty"`
+	PromptVersion int          `json:"prompt_version,omitempty"`
+}
+
+// QueryDeltaEvent carries the next piece of a streamed answer
+type QueryDeltaEvent struct {
+	Content string `json:"content"`
+}
+
+// QueryUsageEvent is the last event of a streamed query
+type QueryUsageEvent struct {
+	Usage   *UsageInfo `json:"usage"`
+	Backend string     `json:"backend,omitempty"` // LLM backend that generated the answer
+}
+
+// QueryErrorEvent reports a streamed query that failed after it started
+type QueryErrorEvent struct {
+	Message string `json:"message"`
 }
 
 // SourceInfo represents source document info
 type SourceInfo struct {
-	DocID      string  `json:"doc_id"`
-	DocName    string  `json:"doc_name"`
-	Content    string  `json:"content"`
-	Similarity float64 `json:"similarity,omitempty"`
+	DocID       string  `json:"doc_id"`
+	DocName     string  `json:"doc_name"`
+	Content     string  `json:"content"`
+	HeadingPath string  `json:"heading_path,omitempty"`
+	Similarity  float64 `json:"similarity,omitempty"`
+}
+
+// ChunkResponse represents a stored chunk and its location in the source file
+type ChunkResponse struct {
+	ID         int            `json:"id"`
+	DocID      string         `json:"doc_id"`
+	DocName    string         `json:"doc_name"`
+	ChunkIndex int            `json:"chunk_index"`
+	ChunkType  string         `json:"chunk_type"`
+	ParentID   *int           `json:"parent_id,omitempty"`
+	Content    string         `json:"content"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+	Location   ChunkLocation  `json:"location"`
+}
+
+// ChunkLocation represents where a chunk lies in its source file. Offsets
+// count characters of the extracted text, end exclusive; lines are 1-based.
+type ChunkLocation struct {
+	FilePath    string `json:"file_path"`
+	EntryPath   string `json:"entry_path,omitempty"`
+	PageNumber  int    `json:"page_number,omitempty"`
+	SheetName   string `json:"sheet_name,omitempty"`
+	SlideNumber int    `json:"slide_number,omitempty"`
+	RowNumber   int    `json:"row_number,omitempty"`
+	HeadingPath string `json:"heading_path,omitempty"`
+	StartOffset *int   `json:"start_offset,omitempty"`
+	EndOffset   *int   `json:"end_offset,omitempty"`
+	StartLine   int    `json:"start_line,omitempty"`
+	EndLine     int    `json:"end_line,omitempty"`
 }
 
 // UsageInfo represents token usage info
 type UsageInfo struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"` // Estimated from the configured price of the model
+	LatencyMs        int64   `json:"latency_ms"`
+}
+
+// UsageRequest represents a usage report request
+type UsageRequest struct {
+	From    string `form:"from"` // First day, YYYY-MM-DD, UTC
+	To      string `form:"to"`   // Last day, YYYY-MM-DD, inclusive
+	Model   string `form:"model"`
+	APIKey  string `form:"api_key"`  // Key fingerprint as reported in the rows
+	GroupBy string `form:"group_by"` // Comma separated day, model and api_key, default all three
+}
+
+// UsageRow represents the usage of one group of queries
+type UsageRow struct {
+	Day              string  `json:"day,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	APIKey           string  `json:"api_key,omitempty"`
+	Queries          int64   `json:"queries"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+}
+
+// UsageResponse represents a usage report
+type UsageResponse struct {
+	Currency string     `json:"currency,omitempty"`
+	Rows     []UsageRow `json:"rows"`
+	Total    UsageRow   `json:"total"`
 }
 
 // ListDocumentsRequest represents list documents request
 type ListDocumentsRequest struct {
 	Page    int `form:"page" binding:"min=1"`
 	PerPage int `form:"per_page" binding:"min=1,max=100"`
-}
\ No newline at end of file
+}
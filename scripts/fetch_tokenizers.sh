@@ -0,0 +1,33 @@
+#!/bin/bash
+
+# Download the tiktoken vocabularies, which are embedded into the binary when
+# it is built afterwards
+
+set -e
+
+DIR="$(cd "$(dirname "$0")/.." && pwd)/internal/eino/tokenizer/vocab"
+BASE_URL="https://openaipublic.blob.core.windows.net/encodings"
+
+fetch() {
+    local name=$1 sum=$2
+    local file="$DIR/$name.tiktoken"
+
+    if [ -f "$file" ] && echo "$sum  $file" | sha256sum -c --status; then
+        echo "✓ $name is up to date"
+        return
+    fi
+
+    echo "Downloading $name..."
+    curl -fsSL -o "$file.tmp" "$BASE_URL/$name.tiktoken"
+    if ! echo "$sum  $file.tmp" | sha256sum -c --status; then
+        rm -f "$file.tmp"
+        echo "Error: checksum mismatch for $name"
+        exit 1
+    fi
+    mv "$file.tmp" "$file"
+    echo "✓ $name"
+}
+
+fetch cl100k_base 223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7
+fetch p50k_base 94b5ca7dff4d00767bc256fdd1b27e5b17361d7b8a5f968547f9f23eb70d2069
+fetch r50k_base 306cd27f03c1a714eca7108e03d66b7dc042abe8c258b44c199a7ed9838dd930
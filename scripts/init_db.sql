@@ -19,6 +19,7 @@ CREATE INDEX IF NOT EXISTS idx_doc_name ON documents(doc_name);
 CREATE INDEX IF NOT EXISTS idx_sync_rag_state ON documents(sync_rag_state);
 CREATE INDEX IF NOT EXISTS idx_sync_enity_state ON documents(sync_enity_state);
 CREATE INDEX IF NOT EXISTS idx_ctime ON documents(ctime);
+CREATE INDEX IF NOT EXISTS idx_file_type ON documents(file_type);
 
 -- 添加注释
 COMMENT ON TABLE documents IS '文档信息管理表';
@@ -31,22 +32,103 @@ COMMENT ON COLUMN documents.sync_rag_state IS '同步向量库状态：0-未同
 COMMENT ON COLUMN documents.sync_enity_state IS '同步实体库状态：0-未同步，1-已同步';
 COMMENT ON COLUMN documents.ctime IS '创建时间';
 
+-- 全文检索分词函数（内置解析器不切分中文），见 migrations/009
+CREATE OR REPLACE FUNCTION search_terms(content text) RETURNS text[]
+LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS $$
+    SELECT coalesce(array_agg(DISTINCT term), '{}')
+    FROM (
+        SELECT lower(m[1]) AS term
+        FROM regexp_matches(content, '([0-9A-Za-z\u00c0-\u024f]+(?:[-_.][0-9A-Za-z\u00c0-\u024f]+)*)', 'g') AS m
+        UNION ALL
+        SELECT lower(m[1])
+        FROM regexp_matches(content, '([0-9A-Za-z\u00c0-\u024f]+)', 'g') AS m
+        UNION ALL
+        SELECT substr(run[1], i, n)
+        FROM regexp_matches(content, '([\u3400-\u4dbf\u4e00-\u9fff\uf900-\ufaff]+)', 'g') AS run,
+             generate_series(1, char_length(run[1])) AS i,
+             generate_series(1, 2) AS n
+        WHERE i + n - 1 <= char_length(run[1])
+    ) terms
+    WHERE char_length(term) <= 100
+$$;
+
+CREATE OR REPLACE FUNCTION search_query(query text) RETURNS tsquery
+LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS $$
+    SELECT coalesce(string_agg(quote_literal(term), ' | '), '')::tsquery
+    FROM unnest(search_terms(query)) AS term
+$$;
+
 -- 文档块表（用于RAG）
 CREATE TABLE IF NOT EXISTS document_chunks (
     id SERIAL PRIMARY KEY,
     doc_id VARCHAR(32) NOT NULL,
     chunk_index INTEGER NOT NULL,
+    chunk_type VARCHAR(10) NOT NULL DEFAULT 'chunk',  -- chunk, parent, child
+    parent_id INTEGER REFERENCES document_chunks(id) ON DELETE CASCADE,
     content TEXT NOT NULL,
-    embedding vector(1536),  -- 需要安装 pgvector 扩展
+    embedding vector,  -- 需要安装 pgvector 扩展，父分块没有向量；维度在服务首次启动时按 eino.embedding.dimension 设定
+    embedding_model VARCHAR(255),       -- provider/model/dimension that produced embedding
+    embedding_next vector,              -- 模型迁移时的新向量
+    embedding_next_model VARCHAR(255),
     metadata JSONB,
+    content_tsv tsvector GENERATED ALWAYS AS (array_to_tsvector(search_terms(content))) STORED,  -- 全文检索词项
     ctime TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (doc_id) REFERENCES documents(doc_id) ON DELETE CASCADE,
     UNIQUE(doc_id, chunk_index)
 );
 
--- 为向量搜索创建索引
-CREATE INDEX IF NOT EXISTS idx_chunk_embedding ON document_chunks USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100);
+-- 为向量搜索创建索引（向量索引需要固定维度，由服务首次启动时创建）
 CREATE INDEX IF NOT EXISTS idx_chunk_doc_id ON document_chunks(doc_id);
+CREATE INDEX IF NOT EXISTS idx_chunk_parent_id ON document_chunks(parent_id);
+CREATE INDEX IF NOT EXISTS idx_chunk_metadata ON document_chunks USING gin(metadata jsonb_path_ops);  -- 查询时按元数据过滤
+CREATE INDEX IF NOT EXISTS idx_chunk_content_tsv ON document_chunks USING gin(content_tsv);  -- 混合检索的全文索引
+
+-- 向量缓存表（按模型和文本哈希复用向量）
+CREATE TABLE IF NOT EXISTS embedding_cache (
+    model VARCHAR(255) NOT NULL,       -- provider/model/dimension
+    content_hash CHAR(64) NOT NULL,    -- SHA-256 of the embedded text
+    embedding vector NOT NULL,         -- 不限维度，不同模型共用一张表
+    ctime TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (model, content_hash)
+);
+
+COMMENT ON TABLE embedding_cache IS '向量缓存表，相同模型下相同文本的向量只请求一次';
+
+-- 查询日志表（token用量与费用统计）
+CREATE TABLE IF NOT EXISTS query_log (
+    id BIGSERIAL PRIMARY KEY,
+    api_key VARCHAR(64) NOT NULL DEFAULT '',   -- SHA-256 fingerprint of the caller's API key
+    query TEXT NOT NULL,
+    backend VARCHAR(255) NOT NULL DEFAULT '',
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    cost NUMERIC(14, 6) NOT NULL DEFAULT 0,  -- 按 usage.prices 估算
+    ctime TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_log_ctime ON query_log(ctime);
+CREATE INDEX IF NOT EXISTS idx_query_log_model ON query_log(model);
+CREATE INDEX IF NOT EXISTS idx_query_log_api_key ON query_log(api_key);
+
+COMMENT ON TABLE query_log IS '查询日志表，记录每次问答的模型、token用量、耗时和估算费用';
+
+-- RAG提示词模板表（按ID和版本管理）
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    prompt_id VARCHAR(100) NOT NULL,
+    version INTEGER NOT NULL CHECK (version > 0),
+    format VARCHAR(20) NOT NULL DEFAULT 'fstring',  -- fstring, go_template, jinja2
+    system_template TEXT,
+    user_template TEXT NOT NULL,
+    document_template TEXT,                        -- 每个检索文档的模板，拼接为 context
+    no_answer TEXT,                                -- 未检索到文档时的回复
+    ctime TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (prompt_id, version)
+);
+
+COMMENT ON TABLE prompt_templates IS 'RAG提示词模板表，每行是一个命名提示词的一个版本';
 
 -- 实体表
 CREATE TABLE IF NOT EXISTS entities (
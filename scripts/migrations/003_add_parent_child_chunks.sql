@@ -0,0 +1,12 @@
+-- Migration: Parent-child chunks for small-to-big retrieval
+-- Small child chunks carry embeddings and are searched; their larger parent
+-- chunk (no embedding) is what gets returned to the LLM.
+
+ALTER TABLE document_chunks ADD COLUMN IF NOT EXISTS chunk_type VARCHAR(10) NOT NULL DEFAULT 'chunk';
+ALTER TABLE document_chunks ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES document_chunks(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_chunk_parent_id ON document_chunks(parent_id);
+
+-- 添加字段注释
+COMMENT ON COLUMN document_chunks.chunk_type IS '分块类型：chunk（普通分块）、parent（父分块，无向量）、child（子分块，检索时返回其父分块）';
+COMMENT ON COLUMN document_chunks.parent_id IS '子分块所属父分块的ID';
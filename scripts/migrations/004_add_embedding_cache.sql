@@ -0,0 +1,18 @@
+-- Migration: Content-addressed embedding cache
+-- Re-processing a document only sends text that was never embedded before
+-- to the provider; everything else is looked up here by model and hash.
+
+CREATE TABLE IF NOT EXISTS embedding_cache (
+    model VARCHAR(255) NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    embedding vector NOT NULL,
+    ctime TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (model, content_hash)
+);
+
+-- 添加表和字段注释
+COMMENT ON TABLE embedding_cache IS '向量缓存表，相同模型下相同文本的向量只请求一次';
+COMMENT ON COLUMN embedding_cache.model IS '向量模型标识：provider/model/dimension';
+COMMENT ON COLUMN embedding_cache.content_hash IS '文本内容的SHA-256哈希';
+COMMENT ON COLUMN embedding_cache.embedding IS '文本向量（不限维度）';
+COMMENT ON COLUMN embedding_cache.ctime IS '创建时间';
@@ -0,0 +1,15 @@
+-- Migration: Record the embedding model of each chunk
+-- Chunks remember which model produced their vector. The re-embedding job
+-- fills embedding_next with the vectors of a new model while embedding keeps
+-- serving queries, then swaps the columns in one transaction.
+-- The dimension of embedding must match eino.embedding.dimension; the server
+-- checks it at startup.
+
+ALTER TABLE document_chunks ADD COLUMN IF NOT EXISTS embedding_model VARCHAR(255);
+ALTER TABLE document_chunks ADD COLUMN IF NOT EXISTS embedding_next vector;
+ALTER TABLE document_chunks ADD COLUMN IF NOT EXISTS embedding_next_model VARCHAR(255);
+
+-- 添加字段注释
+COMMENT ON COLUMN document_chunks.embedding_model IS '生成向量的模型：provider/model/dimension，为空表示迁移前写入';
+COMMENT ON COLUMN document_chunks.embedding_next IS '迁移中的新模型向量，迁移完成后替换 embedding';
+COMMENT ON COLUMN document_chunks.embedding_next_model IS '生成 embedding_next 的模型';
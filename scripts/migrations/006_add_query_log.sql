@@ -0,0 +1,35 @@
+-- Migration: Query log for token usage and cost accounting
+-- Every answered query is recorded with the model that answered it, the
+-- token usage it reported, the latency and the cost estimated from
+-- usage.prices. GET /api/v1/usage aggregates this table.
+
+CREATE TABLE IF NOT EXISTS query_log (
+    id BIGSERIAL PRIMARY KEY,
+    api_key VARCHAR(64) NOT NULL DEFAULT '',
+    query TEXT NOT NULL,
+    backend VARCHAR(255) NOT NULL DEFAULT '',
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    cost NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    ctime TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_log_ctime ON query_log(ctime);
+CREATE INDEX IF NOT EXISTS idx_query_log_model ON query_log(model);
+CREATE INDEX IF NOT EXISTS idx_query_log_api_key ON query_log(api_key);
+
+-- 添加表和字段注释
+COMMENT ON TABLE query_log IS '查询日志表，记录每次问答的模型、token用量、耗时和估算费用';
+COMMENT ON COLUMN query_log.api_key IS '调用方API Key的指纹（SHA-256前16位），不保存原始Key';
+COMMENT ON COLUMN query_log.query IS '用户问题';
+COMMENT ON COLUMN query_log.backend IS '生成回答的LLM后端名称';
+COMMENT ON COLUMN query_log.model IS '生成回答的模型';
+COMMENT ON COLUMN query_log.prompt_tokens IS '输入token数';
+COMMENT ON COLUMN query_log.completion_tokens IS '输出token数';
+COMMENT ON COLUMN query_log.total_tokens IS '总token数';
+COMMENT ON COLUMN query_log.latency_ms IS '查询耗时（毫秒）';
+COMMENT ON COLUMN query_log.cost IS '按 usage.prices 估算的费用';
+COMMENT ON COLUMN query_log.ctime IS '创建时间';
@@ -0,0 +1,28 @@
+-- Migration: Versioned prompt templates for the RAG chain
+-- Rows are read in addition to eino.prompt.templates in the config and
+-- re-read every eino.prompt.reload_interval, so a new version takes effect
+-- without a restart. Queries select a prompt with prompt_id and, optionally,
+-- prompt_version; otherwise the latest version is used.
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    prompt_id VARCHAR(100) NOT NULL,
+    version INTEGER NOT NULL CHECK (version > 0),
+    format VARCHAR(20) NOT NULL DEFAULT 'fstring',
+    system_template TEXT,
+    user_template TEXT NOT NULL,
+    document_template TEXT,
+    no_answer TEXT,
+    ctime TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (prompt_id, version)
+);
+
+-- 添加表和字段注释
+COMMENT ON TABLE prompt_templates IS 'RAG提示词模板表，每行是一个命名提示词的一个版本';
+COMMENT ON COLUMN prompt_templates.prompt_id IS '提示词ID，查询时通过 prompt_id 指定';
+COMMENT ON COLUMN prompt_templates.version IS '版本号，未指定时使用最新版本';
+COMMENT ON COLUMN prompt_templates.format IS '模板语法：fstring、go_template 或 jinja2';
+COMMENT ON COLUMN prompt_templates.system_template IS '系统消息模板，可使用 context 和 query 变量';
+COMMENT ON COLUMN prompt_templates.user_template IS '用户消息模板，可使用 context 和 query 变量';
+COMMENT ON COLUMN prompt_templates.document_template IS '单个检索文档的模板，可使用 index、content、doc_id、heading_path 变量，结果拼接为 context';
+COMMENT ON COLUMN prompt_templates.no_answer IS '未检索到文档时的回复';
+COMMENT ON COLUMN prompt_templates.ctime IS '创建时间';
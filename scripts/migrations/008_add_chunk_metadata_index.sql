@@ -0,0 +1,7 @@
+-- Migration: Index chunk metadata for query filters
+-- Queries can restrict retrieval to chunks whose metadata contains given
+-- keys and values (metadata @> '{"heading_path": "..."}'), which this GIN
+-- index serves. Filters on file type and ctime use the documents table.
+
+CREATE INDEX IF NOT EXISTS idx_chunk_metadata ON document_chunks USING gin(metadata jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_file_type ON documents(file_type);
@@ -0,0 +1,43 @@
+-- Migration: Full-text index over chunk content for hybrid retrieval
+-- Lexical search finds exact identifiers, error codes and SKUs that vector
+-- search misses. The built-in text search parsers do not segment Chinese,
+-- so search_terms extracts the terms itself: identifiers such as ERR-1024
+-- or v1.2.3 as a whole and in their alphanumeric parts, and every Chinese
+-- character and pair of adjacent characters. Queries are split the same
+-- way by search_query, which matches chunks sharing any term.
+-- Adding the generated column rewrites document_chunks.
+
+CREATE OR REPLACE FUNCTION search_terms(content text) RETURNS text[]
+LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS $$
+    SELECT coalesce(array_agg(DISTINCT term), '{}')
+    FROM (
+        SELECT lower(m[1]) AS term
+        FROM regexp_matches(content, '([0-9A-Za-z\u00c0-\u024f]+(?:[-_.][0-9A-Za-z\u00c0-\u024f]+)*)', 'g') AS m
+        UNION ALL
+        SELECT lower(m[1])
+        FROM regexp_matches(content, '([0-9A-Za-z\u00c0-\u024f]+)', 'g') AS m
+        UNION ALL
+        SELECT substr(run[1], i, n)
+        FROM regexp_matches(content, '([\u3400-\u4dbf\u4e00-\u9fff\uf900-\ufaff]+)', 'g') AS run,
+             generate_series(1, char_length(run[1])) AS i,
+             generate_series(1, 2) AS n
+        WHERE i + n - 1 <= char_length(run[1])
+    ) terms
+    WHERE char_length(term) <= 100
+$$;
+
+CREATE OR REPLACE FUNCTION search_query(query text) RETURNS tsquery
+LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS $$
+    SELECT coalesce(string_agg(quote_literal(term), ' | '), '')::tsquery
+    FROM unnest(search_terms(query)) AS term
+$$;
+
+ALTER TABLE document_chunks ADD COLUMN IF NOT EXISTS content_tsv tsvector
+    GENERATED ALWAYS AS (array_to_tsvector(search_terms(content))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_chunk_content_tsv ON document_chunks USING gin(content_tsv);
+
+-- 添加注释
+COMMENT ON FUNCTION search_terms(text) IS '全文检索分词：标识符整体及其字母数字部分，汉字单字与双字';
+COMMENT ON FUNCTION search_query(text) IS '将查询文本按 search_terms 分词，任一词项匹配即可';
+COMMENT ON COLUMN document_chunks.content_tsv IS '内容的全文检索词项，由 content 自动生成，用于混合检索';